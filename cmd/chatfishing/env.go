@@ -10,6 +10,10 @@ import (
 
 type Config struct {
 	SpeciesJson            string
+	GearJson               string
+	BaitJson               string
+	AchievementsJson       string
+	QuestsJson             string
 	DiscordToken           string
 	DevGuild               string
 	DBPath                 string
@@ -17,20 +21,45 @@ type Config struct {
 	ShardId                int
 	CooldownFishingMin     int
 	CooldownFishingMax     int
+	FishingCharges         int
 	CooldownLeaderboardMin int
 	CooldownLeaderboardMax int
+	CooldownBackend        string
+	CooldownBoltPath       string
+	MarketScope            string
+	GlobalLeaderboard      bool
 }
 
 func LoadConfig() (*Config, error) {
 	err := godotenv.Load()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load .env:", err)
+		return nil, fmt.Errorf("failed to load .env: %w", err)
 	}
 	speciesJson := os.Getenv("SPECIES_JSON")
 	if speciesJson == "" {
 		return nil, fmt.Errorf("No SPECIES_JSON in environment")
 	}
 
+	gearJson := os.Getenv("GEAR_JSON")
+	if gearJson == "" {
+		gearJson = "gear/gear.json"
+	}
+
+	baitJson := os.Getenv("BAIT_JSON")
+	if baitJson == "" {
+		baitJson = "gear/bait.json"
+	}
+
+	achievementsJson := os.Getenv("ACHIEVEMENTS_JSON")
+	if achievementsJson == "" {
+		achievementsJson = "achievements/achievements.json"
+	}
+
+	questsJson := os.Getenv("QUESTS_JSON")
+	if questsJson == "" {
+		questsJson = "quests/quests.json"
+	}
+
 	token := os.Getenv("DISCORD_TOKEN")
 	if token == "" {
 		return nil, fmt.Errorf("No DISCORD_TOKEN in environment")
@@ -62,6 +91,10 @@ func LoadConfig() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	fishingCharges, err := loadInt("FISHING_CHARGES", 1)
+	if err != nil {
+		return nil, err
+	}
 	cooldownLeaderboardMin, err := loadInt("COOLDOWN_LEADERBOARD_MIN", 30)
 	if err != nil {
 		return nil, err
@@ -71,8 +104,43 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	cooldownBackend := os.Getenv("COOLDOWN_BACKEND")
+	if cooldownBackend == "" {
+		cooldownBackend = "memory"
+	}
+	cooldownBoltPath := os.Getenv("COOLDOWN_BOLT_PATH")
+	switch cooldownBackend {
+	case "memory", "sqlite":
+	case "bolt":
+		if cooldownBoltPath == "" {
+			return nil, fmt.Errorf("No COOLDOWN_BOLT_PATH in environment (required for COOLDOWN_BACKEND=bolt)")
+		}
+	default:
+		return nil, fmt.Errorf("Unknown COOLDOWN_BACKEND %q (want memory, sqlite or bolt)", cooldownBackend)
+	}
+
+	marketScope := os.Getenv("MARKET_SCOPE")
+	switch marketScope {
+	case "":
+		marketScope = "guild"
+	case "guild", "global":
+	default:
+		return nil, fmt.Errorf("Unknown MARKET_SCOPE %q (want guild or global)", marketScope)
+	}
+
+	globalLeaderboard := false
+	if v := os.Getenv("GLOBAL_LEADERBOARD"); v != "" {
+		if globalLeaderboard, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("Invalid GLOBAL_LEADERBOARD %q (want true or false)", v)
+		}
+	}
+
 	return &Config{
 		SpeciesJson:            speciesJson,
+		GearJson:               gearJson,
+		BaitJson:               baitJson,
+		AchievementsJson:       achievementsJson,
+		QuestsJson:             questsJson,
 		DiscordToken:           token,
 		DevGuild:               devGuild,
 		DBPath:                 dbPath,
@@ -80,8 +148,13 @@ func LoadConfig() (*Config, error) {
 		ShardId:                shardId,
 		CooldownFishingMin:     cooldownFishingMin,
 		CooldownFishingMax:     cooldownFishingMax,
+		FishingCharges:         fishingCharges,
 		CooldownLeaderboardMin: cooldownLeaderboardMin,
 		CooldownLeaderboardMax: cooldownLeaderboardMax,
+		CooldownBackend:        cooldownBackend,
+		CooldownBoltPath:       cooldownBoltPath,
+		MarketScope:            marketScope,
+		GlobalLeaderboard:      globalLeaderboard,
 	}, nil
 }
 
@@ -1,17 +1,20 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // guild timezones shouldn't depend on the host's zoneinfo
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/faideww/chat-fishing/internal/bot"
 	"github.com/faideww/chat-fishing/internal/fish"
 	"github.com/faideww/chat-fishing/internal/ratelimit"
 	"github.com/faideww/chat-fishing/internal/store"
+	bolt "go.etcd.io/bbolt"
 )
 
 func main() {
@@ -25,6 +28,26 @@ func main() {
 		log.Fatal(err)
 	}
 
+	gear, err := fish.LoadGearFromJSON(config.GearJson)
+	if err != nil {
+		log.Fatal("failed to load gear:", err)
+	}
+
+	bait, err := fish.LoadBaitFromJSON(config.BaitJson)
+	if err != nil {
+		log.Fatal("failed to load bait:", err)
+	}
+
+	achievements, err := fish.LoadAchievementsFromJSON(config.AchievementsJson, reg)
+	if err != nil {
+		log.Fatal("failed to load achievements:", err)
+	}
+
+	quests, err := fish.LoadQuestsFromJSON(config.QuestsJson, reg)
+	if err != nil {
+		log.Fatal("failed to load quests:", err)
+	}
+
 	st, err := store.OpenSQLite(config.DBPath)
 	if err != nil {
 		log.Fatal(err)
@@ -46,17 +69,69 @@ func main() {
 
 	appId := session.State.User.ID
 
-	fishLim := ratelimit.NewLimiter(
-		time.Duration(config.CooldownFishingMin)*time.Second,
-		time.Duration(config.CooldownFishingMax)*time.Second,
-		nil,
-	)
-	lbLim := ratelimit.NewLimiter(
+	var boltDb *bolt.DB
+	if config.CooldownBackend == "bolt" {
+		boltDb, err = bolt.Open(config.CooldownBoltPath, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+		if err != nil {
+			log.Fatal("failed to open cooldown db:", err)
+		}
+		defer boltDb.Close()
+	}
+
+	fishBackend, err := cooldownBackend(config.CooldownBackend, st, boltDb, "fishing")
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Every time-driven part of the bot reads the same clock
+	clk := ratelimit.RealClock{}
+	var fishLim ratelimit.Gate
+	if config.FishingCharges > 1 {
+		// Stored "bait charges": COOLDOWN_FISHING_* is the per-charge recharge time
+		fishLim, err = ratelimit.NewTokenBucketWithBackend(
+			config.FishingCharges,
+			time.Duration(config.CooldownFishingMin)*time.Second,
+			time.Duration(config.CooldownFishingMax)*time.Second,
+			clk,
+			fishBackend,
+		)
+	} else {
+		fishLim, err = ratelimit.NewLimiterWithBackend(
+			time.Duration(config.CooldownFishingMin)*time.Second,
+			time.Duration(config.CooldownFishingMax)*time.Second,
+			clk,
+			fishBackend,
+		)
+	}
+	if err != nil {
+		log.Fatal("failed to restore fishing cooldowns:", err)
+	}
+
+	lbBackend, err := cooldownBackend(config.CooldownBackend, st, boltDb, "leaderboard")
+	if err != nil {
+		log.Fatal(err)
+	}
+	lbLim, err := ratelimit.NewLimiterWithBackend(
 		time.Duration(config.CooldownLeaderboardMin)*time.Second,
 		time.Duration(config.CooldownLeaderboardMax)*time.Second,
-		nil,
+		clk,
+		lbBackend,
 	)
-	teardown, err := bot.Setup(session, appId, config.DevGuild, reg, st, fishLim, lbLim)
+	if err != nil {
+		log.Fatal("failed to restore leaderboard cooldowns:", err)
+	}
+	teardown, err := bot.Setup(session, appId, config.DevGuild, bot.Deps{
+		Registry:     reg,
+		Gear:         gear,
+		Bait:         bait,
+		Achievements: achievements,
+		Quests:       quests,
+		Store:        st,
+		FishLim:      fishLim,
+		LbLim:        lbLim,
+		Clock:        clk,
+		GlobalMarket: config.MarketScope == "global",
+		GlobalBoard:  config.GlobalLeaderboard,
+	})
 	if err != nil {
 		log.Fatal("failed to setup bot:", err)
 	}
@@ -67,3 +142,16 @@ func main() {
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
 }
+
+func cooldownBackend(kind string, st *store.SQLiteStore, boltDb *bolt.DB, limiter string) (ratelimit.Backend, error) {
+	switch kind {
+	case "memory":
+		return ratelimit.NewMemoryBackend(), nil
+	case "sqlite":
+		return ratelimit.NewSQLiteBackend(st.DB(), limiter)
+	case "bolt":
+		return ratelimit.NewBoltBackend(boltDb, limiter)
+	default:
+		return nil, fmt.Errorf("unknown cooldown backend %q", kind)
+	}
+}
@@ -0,0 +1,260 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// catchOutcome is everything that follows from landing a fish
+type catchOutcome struct {
+	id       int64
+	unlocked []fish.Achievement
+	quests   []store.QuestAssignment
+	streak   int  // days in a row fished, including this catch
+	newDay   bool // first catch of the guild's day
+}
+
+// recordCatch stores a catch and then works out what it unlocked. Every path
+// that lands a fish should go through here. name is the angler's display
+// name, kept for the global leaderboard.
+func (m *module) recordCatch(c fish.Catch, name string) (catchOutcome, error) {
+	id, err := m.store.Add(context.TODO(), c)
+	if err != nil {
+		return catchOutcome{}, err
+	}
+	c.Id = id
+	out := catchOutcome{id: id}
+	m.offerGlobal(c, name)
+	out.quests = m.advanceQuests(c.GuildId, c.UserId, fish.QuestCatch, []fish.Catch{c}, nil)
+
+	active, err := m.store.ActiveSlots(context.TODO(), c.GuildId, c.UserId)
+	if err != nil {
+		logREST("failed to load catch times", err)
+		return out, nil
+	}
+	loc := m.settings.location(c.GuildId)
+	now := c.CaughtAt.In(loc)
+	today, err := m.store.CaughtSince(context.TODO(), c.GuildId, c.UserId, fish.StartOfDay(now))
+	if err != nil {
+		logREST("failed to count today's catches", err)
+		return out, nil
+	}
+	out.streak = fish.CurrentStreak(active, now)
+	out.newDay = today == 1
+	out.unlocked = m.awardAchievements(c, out.newDay, active, loc)
+	return out, nil
+}
+
+// awardAchievements awards everything c newly unlocked and returns those
+// achievements. Only achievements c counts towards are measured, and the
+// user's catches are only tallied if one of those needs it. active is when
+// the user has been fishing (see store.ActiveSlots).
+func (m *module) awardAchievements(c fish.Catch, newDay bool, active []time.Time, loc *time.Location) []fish.Achievement {
+	guildId, userId := c.GuildId, c.UserId
+	earned, err := m.store.Achievements(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load achievements", err)
+		return nil
+	}
+
+	var candidates []fish.Achievement
+	needTally := false
+	for _, a := range m.achievements.All() {
+		if _, ok := earned[a.Key]; ok || !a.AdvancedBy(m.picker, c, newDay) {
+			continue
+		}
+		candidates = append(candidates, a)
+		needTally = needTally || a.StreakDays == 0
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var tally fish.CatchTally
+	if needTally {
+		if tally, err = m.store.CatchTally(context.TODO(), guildId, userId, m.reg); err != nil {
+			logREST("failed to tally catches", err)
+			return nil
+		}
+	}
+
+	var unlocked []fish.Achievement
+	for _, a := range candidates {
+		if have, need := a.Progress(m.picker, tally, active, loc); need > 0 && have >= need {
+			unlocked = append(unlocked, a)
+		}
+	}
+	if len(unlocked) == 0 {
+		return nil
+	}
+	keys := make([]string, len(unlocked))
+	for idx, a := range unlocked {
+		keys[idx] = a.Key
+	}
+
+	awarded, err := m.store.AwardAchievements(context.TODO(), guildId, userId, keys)
+	if err != nil {
+		logREST("failed to award achievements", err)
+		return nil
+	}
+	out := make([]fish.Achievement, 0, len(awarded))
+	for _, key := range awarded {
+		if a, ok := m.achievements.Get(key); ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// catchProgress loads what achievements are measured against: the user's
+// catches tallied by species and size, and when they were fishing
+func (m *module) catchProgress(guildId, userId int64) (fish.CatchTally, []time.Time, error) {
+	tally, err := m.store.CatchTally(context.TODO(), guildId, userId, m.reg)
+	if err != nil {
+		return nil, nil, err
+	}
+	active, err := m.store.ActiveSlots(context.TODO(), guildId, userId)
+	return tally, active, err
+}
+
+// titleFor returns the display text of the user's equipped title, or ""
+func (m *module) titleFor(guildId, userId int64) string {
+	key, err := m.store.EquippedTitle(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load title", err)
+	}
+	a, _ := m.achievements.Get(key)
+	return a.Title
+}
+
+// titlesFor is titleFor for many users at once
+func (m *module) titlesFor(guildId int64, userIds []int64) map[int64]string {
+	keys, err := m.store.EquippedTitles(context.TODO(), guildId, userIds)
+	if err != nil {
+		logREST("failed to load titles", err)
+	}
+	out := make(map[int64]string, len(keys))
+	for userId, key := range keys {
+		if a, ok := m.achievements.Get(key); ok && a.Title != "" {
+			out[userId] = a.Title
+		}
+	}
+	return out
+}
+
+func withTitle(name, title string) string {
+	if title == "" {
+		return name
+	}
+	return fmt.Sprintf("%s, *%s*", name, title)
+}
+
+func (m *module) handleTitles(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/titles must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	guildId, userId := toInt64(i.GuildID), toInt64(interactionUserId(i))
+	earned, err := m.store.Achievements(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load achievements", err)
+		respondEphemeral(s, i, "Error loading your achievements.")
+		return
+	}
+
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name != "title" {
+			continue
+		}
+		key := opt.StringValue()
+		if key == "none" {
+			if err := m.store.EquipTitle(context.TODO(), guildId, userId, ""); err != nil {
+				logREST("failed to clear title", err)
+				respondEphemeral(s, i, "Error changing your title.")
+				return
+			}
+			respondEphemeral(s, i, "Title removed.")
+			return
+		}
+
+		a, ok := m.achievements.Get(key)
+		if !ok || a.Title == "" {
+			respondEphemeral(s, i, fmt.Sprintf("Unknown title '%s' - see `/titles`.", key))
+			return
+		}
+		if _, ok := earned[key]; !ok {
+			respondEphemeral(s, i, fmt.Sprintf("Unlock **%s** (%s) to use that title.", a.Name, a.Description))
+			return
+		}
+		if err := m.store.EquipTitle(context.TODO(), guildId, userId, key); err != nil {
+			logREST("failed to equip title", err)
+			respondEphemeral(s, i, "Error changing your title.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("You're now known as **%s**.", a.Title))
+		return
+	}
+
+	// No title given: show progress on every achievement
+	tally, active, err := m.catchProgress(guildId, userId)
+	if err != nil {
+		logREST("failed to load catch progress", err)
+	}
+	loc := m.settings.location(guildId)
+
+	v := view.TitlesView{Earned: len(earned), Total: len(m.achievements.All()), Equipped: m.titleFor(guildId, userId)}
+	for _, a := range m.achievements.All() {
+		row := view.TitleRow{Name: a.Name, Description: a.Description, Title: a.Title, Redeemable: a.Cost > 0}
+		if at, ok := earned[a.Key]; ok {
+			row.Earned, row.EarnedAt = true, at.Unix()
+		} else if a.Cost == 0 {
+			row.Have, row.Need = a.Progress(m.picker, tally, active, loc)
+		}
+		v.Rows = append(v.Rows, row)
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{view.TitlesEmbed(v, m.settings.templates(guildId))},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func (m *module) handleTitlesAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var typed string
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "title" && opt.Focused {
+			typed = opt.StringValue()
+		}
+	}
+
+	earned, err := m.store.Achievements(context.TODO(), toInt64(i.GuildID), toInt64(interactionUserId(i)))
+	if err != nil {
+		logREST("failed to load achievements", err)
+	}
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, a := range m.achievements.All() {
+		if _, ok := earned[a.Key]; !ok || a.Title == "" || !matchesTyped(typed, a.Key, a.Title) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s (%s)", a.Title, a.Name),
+			Value: a.Key,
+		})
+	}
+	if matchesTyped(typed, "none", "No title") {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: "No title", Value: "none"})
+	}
+	respondAutocomplete(s, i, limitChoices(choices))
+}
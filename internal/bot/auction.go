@@ -0,0 +1,336 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+const (
+	auctionDefaultHours = 24
+	auctionTick         = 30 * time.Second
+	auctionListLimit    = 15
+)
+
+func (m *module) handleAuction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/auction must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		respondEphemeral(s, i, "Choose an auction action.")
+		return
+	}
+	sub := data.Options[0]
+
+	var catchId, auctionId, amount, minBid int64
+	hours := int64(auctionDefaultHours)
+	for _, opt := range sub.Options {
+		switch opt.Name {
+		case "catch":
+			catchId = opt.IntValue()
+		case "auction":
+			auctionId = opt.IntValue()
+		case "amount":
+			amount = opt.IntValue()
+		case "min_bid":
+			minBid = opt.IntValue()
+		case "hours":
+			hours = opt.IntValue()
+		}
+	}
+
+	switch sub.Name {
+	case "list":
+		if catchId == 0 {
+			m.showAuctions(s, i)
+		} else {
+			m.listAuction(s, i, catchId, minBid, time.Duration(hours)*time.Hour)
+		}
+	case "bid":
+		m.bidAuction(s, i, auctionId, amount)
+	case "cancel":
+		m.cancelAuction(s, i, auctionId)
+	}
+}
+
+func (m *module) showAuctions(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	auctions, err := m.store.OpenAuctions(context.TODO(), toInt64(i.GuildID))
+	if err != nil {
+		logREST("failed to load auctions", err)
+		respondEphemeral(s, i, "Error loading auctions.")
+		return
+	}
+
+	var v view.AuctionsView
+	for idx, a := range auctions {
+		if idx == auctionListLimit {
+			v.More = len(auctions) - idx
+			break
+		}
+		v.Rows = append(v.Rows, m.auctionView(a))
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{view.AuctionListEmbed(v, m.settings.templates(toInt64(i.GuildID)))},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// auctionView describes an auction for an embed
+func (m *module) auctionView(a store.Auction) view.AuctionView {
+	sp, _ := m.reg.GetById(a.Catch.SpeciesId)
+	tier := m.picker.SpeciesTier(a.Catch.SpeciesId)
+	return view.AuctionView{
+		Id:          a.Id,
+		SellerId:    a.SellerId,
+		Species:     sp.Name,
+		Size:        a.Catch.Size,
+		Tier:        tier.String(),
+		Color:       fish.ColorForTier(tier),
+		ThumbURL:    sp.Image,
+		MinBid:      a.MinBid,
+		TopBid:      a.TopBid,
+		TopBidderId: a.TopBidderId,
+		EndsAt:      a.EndsAt.Unix(),
+		Sold:        a.Status == store.AuctionSold,
+	}
+}
+
+func (m *module) listAuction(s *discordgo.Session, i *discordgo.InteractionCreate, catchId, minBid int64, length time.Duration) {
+	guildId, userId := toInt64(i.GuildID), toInt64(interactionUserId(i))
+
+	unsold, err := m.store.UnsoldCatches(context.TODO(), guildId, userId, -1)
+	if err != nil {
+		logREST("failed to load catches", err)
+		respondEphemeral(s, i, "Error loading your catches.")
+		return
+	}
+	var (
+		c     fish.Catch
+		found bool
+	)
+	for _, u := range unsold {
+		if u.Id == catchId {
+			c, found = u, true
+			break
+		}
+	}
+	if !found {
+		respondEphemeral(s, i, fmt.Sprintf("You don't have an unsold catch #%d.", catchId))
+		return
+	}
+
+	tier := m.picker.SpeciesTier(c.SpeciesId)
+	if tier < fish.TierRare {
+		respondEphemeral(s, i, fmt.Sprintf("Only %s or rarer catches can be auctioned - sell this one with `/sell`.", fish.TierRare.String()))
+		return
+	}
+
+	if minBid <= 0 {
+		minBid = m.priceOf(c, m.market.factors(guildId))
+	}
+
+	endsAt := time.Now().Add(length)
+	id, err := m.store.CreateAuction(context.TODO(), guildId, toInt64(i.ChannelID), userId, c.Id, minBid, endsAt)
+	if errors.Is(err, store.ErrCatchNotOwned) {
+		respondEphemeral(s, i, fmt.Sprintf("You don't have an unsold catch #%d.", catchId))
+		return
+	}
+	if err != nil {
+		logREST("failed to create auction", err)
+		respondEphemeral(s, i, "Error creating the auction.")
+		return
+	}
+
+	sp, _ := m.reg.GetById(c.SpeciesId)
+	v := view.AuctionView{
+		Id:       id,
+		SellerId: userId,
+		Species:  sp.Name,
+		Size:     c.Size,
+		Tier:     tier.String(),
+		Color:    fish.ColorForTier(tier),
+		ThumbURL: sp.Image,
+		MinBid:   max(minBid, 1),
+		EndsAt:   endsAt.Unix(),
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{view.AuctionNewEmbed(v, m.settings.templates(guildId))},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func (m *module) bidAuction(s *discordgo.Session, i *discordgo.InteractionCreate, auctionId, amount int64) {
+	userId := toInt64(interactionUserId(i))
+
+	before, err := m.store.PlaceBid(context.TODO(), toInt64(i.GuildID), auctionId, userId, amount)
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrNoAuction):
+		respondEphemeral(s, i, fmt.Sprintf("There's no auction #%d - see `/auction list`.", auctionId))
+		return
+	case errors.Is(err, store.ErrAuctionClosed):
+		respondEphemeral(s, i, fmt.Sprintf("Auction #%d has already ended.", auctionId))
+		return
+	case errors.Is(err, store.ErrOwnAuction):
+		respondEphemeral(s, i, "You can't bid on your own auction.")
+		return
+	case errors.Is(err, store.ErrBidTooLow):
+		respondEphemeral(s, i, fmt.Sprintf("Bids on auction #%d must be at least %d 🪙.", auctionId, before.NextMinBid()))
+		return
+	case errors.Is(err, store.ErrInsufficientFunds):
+		respondEphemeral(s, i, fmt.Sprintf("You don't have %d 🪙.", amount))
+		return
+	default:
+		logREST("failed to place bid", err)
+		respondEphemeral(s, i, "Error placing your bid.")
+		return
+	}
+
+	sp, _ := m.reg.GetById(before.Catch.SpeciesId)
+	msg := fmt.Sprintf("🔨 <@%d> bid **%d** 🪙 on auction #%d (%s, %.1f cm) · ends <t:%d:R>",
+		userId, amount, auctionId, sp.Name, before.Catch.Size, before.EndsAt.Unix())
+	mentions := &discordgo.MessageAllowedMentions{}
+	if before.TopBidderId != 0 && before.TopBidderId != userId {
+		prev := strconv.FormatInt(before.TopBidderId, 10)
+		msg += fmt.Sprintf("\n<@%s>, you've been outbid - your %d 🪙 were refunded.", prev, before.TopBid)
+		mentions.Users = []string{prev}
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         msg,
+			AllowedMentions: mentions,
+		},
+	})
+}
+
+func (m *module) cancelAuction(s *discordgo.Session, i *discordgo.InteractionCreate, auctionId int64) {
+	err := m.store.CancelAuction(context.TODO(), auctionId, toInt64(interactionUserId(i)))
+	switch {
+	case err == nil:
+		respondEphemeral(s, i, fmt.Sprintf("Auction #%d cancelled - the fish is back in your `/inventory`.", auctionId))
+	case errors.Is(err, store.ErrNoAuction), errors.Is(err, store.ErrNotSeller):
+		respondEphemeral(s, i, fmt.Sprintf("You don't have an auction #%d.", auctionId))
+	case errors.Is(err, store.ErrAuctionClosed):
+		respondEphemeral(s, i, fmt.Sprintf("Auction #%d has already ended.", auctionId))
+	case errors.Is(err, store.ErrHasBids):
+		respondEphemeral(s, i, "Auctions can't be cancelled once someone has bid.")
+	default:
+		logREST("failed to cancel auction", err)
+		respondEphemeral(s, i, "Error cancelling the auction.")
+	}
+}
+
+// startAuctions settles ended auctions and posts their results in the
+// background. Both steps are idempotent in the store, so it simply catches
+// up on anything missed while the bot was down.
+func (m *module) startAuctions() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(auctionTick)
+		defer ticker.Stop()
+		for {
+			m.settleAuctions(time.Now())
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// handlesGuild reports whether this shard is connected to the guild, so each
+// auction is only settled and announced by one process
+func (m *module) handlesGuild(guildId int64) bool {
+	_, err := m.s.State.Guild(strconv.FormatInt(guildId, 10))
+	return err == nil
+}
+
+// retryableREST reports whether a failed request is worth retrying on the next
+// tick, i.e. Discord didn't reject it for good (e.g. the channel is gone or
+// we lost access)
+func retryableREST(err error) bool {
+	var rerr *discordgo.RESTError
+	return !errors.As(err, &rerr) || rerr.Response == nil || rerr.Response.StatusCode >= http.StatusInternalServerError
+}
+
+func (m *module) settleAuctions(now time.Time) {
+	due, err := m.store.DueAuctions(context.TODO(), now)
+	if err != nil {
+		log.Printf("failed to load due auctions: %v", err)
+		return
+	}
+	for _, a := range due {
+		if !m.handlesGuild(a.GuildId) {
+			continue
+		}
+		if _, err := m.store.SettleAuction(context.TODO(), a.Id, now); err != nil && !errors.Is(err, store.ErrAuctionClosed) {
+			log.Printf("failed to settle auction %d: %v", a.Id, err)
+		}
+	}
+
+	settled, err := m.store.UnannouncedAuctions(context.TODO())
+	if err != nil {
+		log.Printf("failed to load settled auctions: %v", err)
+		return
+	}
+	for _, a := range settled {
+		if !m.handlesGuild(a.GuildId) {
+			continue
+		}
+		m.announceAuction(a)
+	}
+}
+
+func (m *module) announceAuction(a store.Auction) {
+	embed := view.AuctionClosedEmbed(m.auctionView(a), m.settings.templates(a.GuildId))
+	seller := strconv.FormatInt(a.SellerId, 10)
+	mentions := []string{seller}
+	if a.Status == store.AuctionSold {
+		mentions = append(mentions, strconv.FormatInt(a.TopBidderId, 10))
+	}
+
+	_, err := m.s.ChannelMessageSendComplex(strconv.FormatInt(a.ChannelId, 10), &discordgo.MessageSend{
+		Content:         "<@" + strings.Join(mentions, "> <@") + ">",
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: mentions},
+	})
+	if err != nil {
+		logREST(fmt.Sprintf("failed to announce auction %d", a.Id), err)
+		if retryableREST(err) {
+			return
+		}
+	}
+	if err := m.store.MarkAuctionAnnounced(context.TODO(), a.Id); err != nil {
+		log.Printf("failed to mark auction %d announced: %v", a.Id, err)
+	}
+}
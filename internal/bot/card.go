@@ -0,0 +1,29 @@
+package bot
+
+import (
+	"bytes"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// catchCard renders the shareable card for a landed catch, or returns nil if
+// it can't be drawn
+func (m *module) catchCard(c cast, sp fish.Species, tier fish.RarityTier) *discordgo.File {
+	png, err := view.RenderCatchCard(view.CatchCard{
+		Species:   sp.Name,
+		Art:       view.SpeciesArt(sp.Key),
+		Size:      c.catch.Size,
+		SizeClass: fish.SizeClassFor(sp, c.catch.Size),
+		Tier:      tier,
+		Angler:    c.name,
+		CaughtAt:  c.catch.CaughtAt.In(m.settings.location(c.catch.GuildId)),
+	})
+	if err != nil {
+		log.Printf("failed to render catch card: %v", err)
+		return nil
+	}
+	return &discordgo.File{Name: view.CatchCardName, ContentType: "image/png", Reader: bytes.NewReader(png)}
+}
@@ -1,10 +1,45 @@
 package bot
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+)
+
+var manageGuildPerm int64 = discordgo.PermissionManageGuild
+
+func floatPtr(f float64) *float64 { return &f }
 
 func commandDefs() []*discordgo.ApplicationCommand {
 	return []*discordgo.ApplicationCommand{
-		{Name: "fish", Description: "Cast a line"},
+		{
+			Name:        "fish",
+			Description: "Cast a line",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "bait",
+					Description:  "Use one of your bait",
+					Autocomplete: true,
+				},
+			},
+		},
+		{Name: "cooldown", Description: "Show your active cooldowns"},
+		{Name: "quests", Description: "See your daily and weekly quests"},
+		{Name: "daily", Description: "Claim your daily reward - it grows with your fishing streak"},
+		{Name: "forecast", Description: "See the weather on the water and what it brings out"},
+		{
+			Name:        "profile",
+			Description: "Show an angler's stats",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Whose profile to show (default you)",
+				},
+			},
+		},
 		{
 			Name:        "leaderboard",
 			Description: "Show the biggest catches",
@@ -15,7 +50,491 @@ func commandDefs() []*discordgo.ApplicationCommand {
 					Description: "Filter by species key",
 					Required:    false,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "scope",
+					Description: "Rank single catches, whole teams, or catches from every server",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Catches", Value: "catches"},
+						{Name: "Teams", Value: "team"},
+						{Name: "Global", Value: "global"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "period",
+					Description: "Time window for team stats (default all time)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Past day", Value: "day"},
+						{Name: "Past week", Value: "week"},
+						{Name: "Past month", Value: "month"},
+						{Name: "All time", Value: "all"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "team",
+			Description: "Fish together as a team",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "create",
+					Description: "Found a new team and join it",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Team name",
+							Required:    true,
+							MaxLength:   32,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "join",
+					Description: "Join a team, leaving your current one",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "Team name",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "leave",
+					Description: "Leave your team",
+				},
+			},
+		},
+		{
+			Name:        "sell",
+			Description: "Sell fish at the market",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "catch",
+					Description: "Sell a single catch",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "Catch number (shown on /fish)",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "species",
+					Description: "Sell every unsold fish of one species",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "species",
+							Description: "Species key",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "below",
+					Description: "Sell every unsold fish smaller than a size class",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "size",
+							Description: "Size class",
+							Required:    true,
+							Choices:     sizeClassChoices(fish.SizeSmall),
+						},
+					},
+				},
 			},
 		},
+		{
+			Name:        "inventory",
+			Description: "List the fish you're holding",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "species",
+					Description: "Filter by species key",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "tier",
+					Description: "Filter by rarity",
+					Choices:     tierChoices(),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "size",
+					Description: "Filter by size class",
+					Choices:     sizeClassChoices(fish.SizeTiny),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "sort",
+					Description: "Sort order",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "newest", Value: "new"},
+						{Name: "biggest", Value: "big"},
+						{Name: "most valuable", Value: "value"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "market",
+			Description: "Show today's fish prices",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "species",
+					Description: "Show one species",
+				},
+			},
+		},
+		{Name: "shop", Description: "Browse rods, reels, lines and bait"},
+		{
+			Name:        "buy",
+			Description: "Buy bait, or buy (or re-equip) a piece of gear",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "item",
+					Description:  "Item to buy",
+					Required:     true,
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:        "trade",
+			Description: "Trade fish and coins with another player",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Who to trade with",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "auction",
+			Description: "Auction Rare or better catches to the highest bidder",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Put a catch up for auction, or show running auctions",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "catch",
+							Description: "Catch number to auction (leave empty to browse)",
+							MinValue:    floatPtr(1),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "min_bid",
+							Description: "Minimum bid (defaults to the market price)",
+							MinValue:    floatPtr(1),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "hours",
+							Description: "How long the auction runs (default 24)",
+							MinValue:    floatPtr(1),
+							MaxValue:    72,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "bid",
+					Description: "Bid on an auction",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "auction",
+							Description: "Auction number",
+							Required:    true,
+							MinValue:    floatPtr(1),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "amount",
+							Description: "Your bid",
+							Required:    true,
+							MinValue:    floatPtr(1),
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "cancel",
+					Description: "Cancel your auction if nobody has bid yet",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "auction",
+							Description: "Auction number",
+							Required:    true,
+							MinValue:    floatPtr(1),
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "tournament",
+			Description: "Timed fishing competitions",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "create",
+					Description: "Schedule a tournament (Manage Server)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Tournament name",
+							Required:    true,
+							MaxLength:   80,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "minutes",
+							Description: "How long it runs",
+							Required:    true,
+							MinValue:    floatPtr(5),
+							MaxValue:    tournamentMaxMinutes,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "metric",
+							Description: "How entrants are scored (default largest catch)",
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Largest catch", Value: fish.MetricLargest},
+								{Name: "Most catches", Value: fish.MetricMost},
+								{Name: "Total size percentile", Value: fish.MetricPercentile},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "species",
+							Description: "Only count this species key",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "tag",
+							Description: "Only count fish with this tag, e.g. river",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "start",
+							Description: "Start time in server time, e.g. 20:00 (default now)",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "prize",
+							Description: "Prize pool in coins, split 50/30/20 between the top three",
+							MinValue:    floatPtr(0),
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "join",
+					Description: "Enter a tournament",
+					Options:     []*discordgo.ApplicationCommandOption{tournamentOption()},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "status",
+					Description: "Show standings or results",
+					Options:     []*discordgo.ApplicationCommandOption{tournamentOption()},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "end",
+					Description: "End a tournament now (Manage Server)",
+					Options:     []*discordgo.ApplicationCommandOption{tournamentOption()},
+				},
+			},
+		},
+		{
+			Name:        "titles",
+			Description: "Show your achievements, or equip a title you've unlocked",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "title",
+					Description:  "Title to equip",
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:        "conservation",
+			Description: "Show your conservation points, or spend them on a title",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "redeem",
+					Description:  "Title to redeem",
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:                     "config",
+			Description:              "Change bot settings for this server",
+			DefaultMemberPermissions: &manageGuildPerm,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "timezone",
+					Description: "Set when the day rolls over for streaks and /daily (omit to reset to UTC)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "zone",
+							Description: "IANA timezone, e.g. Europe/London",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "reeling",
+					Description: "Make /fish wait for a bite that has to be reeled in",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Turn the reeling minigame on or off",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "fights",
+					Description: "Make huge fish put up a fight before they land",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Turn fights on or off",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "global",
+					Description: "List this server's catches on the cross-server leaderboard",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Show catches from this server on the global leaderboard",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "template",
+					Description: "Change the wording of a bot message (omit text to reset)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "Which part of which message",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "text",
+							Description: "Go text/template, e.g. {{.Angler}} landed {{.Species}}! Use \\n for a line break",
+							MaxLength:   1024,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "cooldown",
+					Description: "Command cooldowns",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "fishing",
+							Description: "Set the /fish cooldown range (omit both to reset)",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionInteger,
+									Name:        "min",
+									Description: "Minimum cooldown in seconds",
+									MinValue:    floatPtr(5),
+									MaxValue:    86400,
+								},
+								{
+									Type:        discordgo.ApplicationCommandOptionInteger,
+									Name:        "max",
+									Description: "Maximum cooldown in seconds",
+									MinValue:    floatPtr(5),
+									MaxValue:    86400,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// sizeClassChoices lists size classes from `from` upwards as option choices
+func sizeClassChoices(from fish.SizeClass) []*discordgo.ApplicationCommandOptionChoice {
+	var out []*discordgo.ApplicationCommandOptionChoice
+	for c := from; c <= fish.SizeEnormous; c++ {
+		out = append(out, &discordgo.ApplicationCommandOptionChoice{
+			Name:  c.String(),
+			Value: strconv.Itoa(int(c)),
+		})
+	}
+	return out
+}
+
+func tierChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var out []*discordgo.ApplicationCommandOptionChoice
+	for t := fish.TierCommon; t <= fish.TierMythic; t++ {
+		out = append(out, &discordgo.ApplicationCommandOptionChoice{
+			Name:  t.String(),
+			Value: strconv.Itoa(int(t)),
+		})
+	}
+	return out
+}
+
+// tournamentOption picks a tournament; it can be left out while only one is
+// running
+func tournamentOption() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "tournament",
+		Description: "Tournament number (defaults to the running one)",
+		MinValue:    floatPtr(1),
 	}
 }
@@ -0,0 +1,283 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+const (
+	minGuildCooldown = 5 * time.Second
+	maxGuildCooldown = 24 * time.Hour
+)
+
+func (m *module) handleConfig(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/config must be run in a server)
+	if i.GuildID == "" || i.Member == nil {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	// default_member_permissions hides the command, but server admins can
+	// override that in the integration settings, so check again here
+	if i.Member.Permissions&discordgo.PermissionManageGuild == 0 {
+		respondEphemeral(s, i, "You need the **Manage Server** permission to change settings.")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		respondEphemeral(s, i, "Unknown setting.")
+		return
+	}
+
+	// Settings are either a subcommand or a subcommand in a group
+	top := data.Options[0]
+	if top.Type == discordgo.ApplicationCommandOptionSubCommand {
+		switch top.Name {
+		case "timezone":
+			m.configTimezone(s, i, top.Options)
+		case "reeling":
+			m.configReeling(s, i, top.Options)
+		case "fights":
+			m.configFights(s, i, top.Options)
+		case "global":
+			m.configGlobal(s, i, top.Options)
+		case "template":
+			m.configTemplate(s, i, top.Options)
+		default:
+			respondEphemeral(s, i, "Unknown setting.")
+		}
+		return
+	}
+
+	if len(top.Options) == 0 {
+		respondEphemeral(s, i, "Unknown setting.")
+		return
+	}
+	group, sub := top, top.Options[0]
+
+	switch {
+	case group.Name == "cooldown" && sub.Name == "fishing":
+		m.configFishingCooldown(s, i, sub.Options)
+	default:
+		respondEphemeral(s, i, "Unknown setting.")
+	}
+}
+
+func (m *module) configTimezone(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	var zone string
+	for _, opt := range opts {
+		if opt.Name == "zone" {
+			zone = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	loc := time.UTC
+	if zone != "" {
+		var err error
+		if loc, err = time.LoadLocation(zone); err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Unknown timezone '%s' - use a name like `Europe/London` or `America/New_York`.", zone))
+			return
+		}
+		zone = loc.String()
+	}
+
+	guildId := toInt64(i.GuildID)
+	if err := m.store.SetTimezone(context.TODO(), guildId, zone); err != nil {
+		logREST("failed to save timezone", err)
+		respondEphemeral(s, i, "Failed to save settings.")
+		return
+	}
+	m.settings.invalidate(guildId)
+
+	respondEphemeral(s, i, fmt.Sprintf("Timezone set to **%s** (it's %s there). Daily streaks and rewards roll over at midnight.",
+		loc.String(), time.Now().In(loc).Format("15:04 on Jan 2")))
+}
+
+func (m *module) configReeling(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	var on bool
+	for _, opt := range opts {
+		if opt.Name == "enabled" {
+			on = opt.BoolValue()
+		}
+	}
+
+	guildId := toInt64(i.GuildID)
+	if err := m.store.SetReeling(context.TODO(), guildId, on); err != nil {
+		logREST("failed to save reeling", err)
+		respondEphemeral(s, i, "Failed to save settings.")
+		return
+	}
+	m.settings.invalidate(guildId)
+
+	if on {
+		respondEphemeral(s, i, "Reeling is on: `/fish` now waits for a bite, and anglers have to press **Reel** in time to land it.")
+	} else {
+		respondEphemeral(s, i, "Reeling is off: `/fish` lands a catch straight away.")
+	}
+}
+
+func (m *module) configFights(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	var on bool
+	for _, opt := range opts {
+		if opt.Name == "enabled" {
+			on = opt.BoolValue()
+		}
+	}
+
+	guildId := toInt64(i.GuildID)
+	if err := m.store.SetFights(context.TODO(), guildId, on); err != nil {
+		logREST("failed to save fights", err)
+		respondEphemeral(s, i, "Failed to save settings.")
+		return
+	}
+	m.settings.invalidate(guildId)
+
+	if on {
+		respondEphemeral(s, i, "Fights are on: huge and enormous fish have to be played in with **Reel** and **Slack** before they land. Stronger line helps!")
+	} else {
+		respondEphemeral(s, i, "Fights are off: every fish lands straight away.")
+	}
+}
+
+func (m *module) configGlobal(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	on := true
+	for _, opt := range opts {
+		if opt.Name == "enabled" {
+			on = opt.BoolValue()
+		}
+	}
+
+	guildId := toInt64(i.GuildID)
+	if err := m.store.SetGlobalOptOut(context.TODO(), guildId, !on); err != nil {
+		logREST("failed to save global opt-out", err)
+		respondEphemeral(s, i, "Failed to save settings.")
+		return
+	}
+	m.settings.invalidate(guildId)
+
+	if on {
+		respondEphemeral(s, i, "New catches from this server can appear on the global leaderboard, under the angler's display name.")
+	} else {
+		respondEphemeral(s, i, "This server's catches have been removed from the global leaderboard and won't be added again.")
+	}
+}
+
+func (m *module) configTemplate(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	var name, text string
+	for _, opt := range opts {
+		switch opt.Name {
+		case "name":
+			name = opt.StringValue()
+		case "text":
+			// Slash command options can't hold line breaks, so accept \n instead
+			text = strings.ReplaceAll(strings.TrimSpace(opt.StringValue()), `\n`, "\n")
+		}
+	}
+
+	if !slices.Contains(view.TemplateNames(), name) {
+		respondEphemeral(s, i, fmt.Sprintf("Unknown template '%s'.", name))
+		return
+	}
+
+	guildId := toInt64(i.GuildID)
+	var preview string
+	if text != "" {
+		var err error
+		if preview, err = view.ValidateTemplate(name, text); err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("That template doesn't work: %v", err))
+			return
+		}
+	}
+
+	if err := m.store.SetTemplate(context.TODO(), guildId, name, text); err != nil {
+		logREST("failed to save template", err)
+		respondEphemeral(s, i, "Failed to save settings.")
+		return
+	}
+	m.settings.invalidate(guildId)
+
+	if text == "" {
+		respondEphemeral(s, i, fmt.Sprintf("`%s` is back to the default.", name))
+		return
+	}
+	respondEphemeral(s, i, fmt.Sprintf("Saved `%s`. With sample data it reads:\n>>> %s", name, preview))
+}
+
+func (m *module) configFishingCooldown(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	var min, max time.Duration
+	for _, opt := range opts {
+		switch opt.Name {
+		case "min":
+			min = time.Duration(opt.IntValue()) * time.Second
+		case "max":
+			max = time.Duration(opt.IntValue()) * time.Second
+		}
+	}
+
+	guildId := toInt64(i.GuildID)
+
+	// No options resets the guild back to the bot-wide defaults
+	if min == 0 && max == 0 {
+		if err := m.store.SetFishingCooldown(context.TODO(), guildId, 0, 0); err != nil {
+			logREST("failed to reset cooldown", err)
+			respondEphemeral(s, i, "Failed to save settings.")
+			return
+		}
+		m.settings.invalidate(guildId)
+
+		defMin, defMax := m.fishLim.Defaults()
+		respondEphemeral(s, i, fmt.Sprintf("Fishing cooldown reset to the default (%s–%s).", pretty(defMin), pretty(defMax)))
+		return
+	}
+
+	if min == 0 {
+		min = max
+	}
+	if max == 0 {
+		max = min
+	}
+	if min > max {
+		respondEphemeral(s, i, "`min` must not be greater than `max`.")
+		return
+	}
+	if min < minGuildCooldown || max > maxGuildCooldown {
+		respondEphemeral(s, i, fmt.Sprintf("Cooldowns must be between %s and %s.", pretty(minGuildCooldown), pretty(maxGuildCooldown)))
+		return
+	}
+
+	if err := m.store.SetFishingCooldown(context.TODO(), guildId, min, max); err != nil {
+		logREST("failed to save cooldown", err)
+		respondEphemeral(s, i, "Failed to save settings.")
+		return
+	}
+	m.settings.invalidate(guildId)
+
+	respondEphemeral(s, i, fmt.Sprintf("Fishing cooldown set to %s–%s.", pretty(min), pretty(max)))
+}
+
+// handleConfigAutocomplete suggests template names for /config template
+func (m *module) handleConfigAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var typed string
+	for _, top := range i.ApplicationCommandData().Options {
+		for _, opt := range top.Options {
+			if top.Name == "template" && opt.Name == "name" && opt.Focused {
+				typed = opt.StringValue()
+			}
+		}
+	}
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, name := range view.TemplateNames() {
+		if matchesTyped(typed, name, name) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+		}
+	}
+	respondAutocomplete(s, i, limitChoices(choices))
+}
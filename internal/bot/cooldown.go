@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+func (m *module) handleCooldown(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/cooldown must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	userId := interactionUserId(i)
+	v := view.CooldownView{Lines: m.cooldownLines(i.GuildID, userId)}
+	embed := view.CooldownEmbed(v, m.settings.templates(toInt64(i.GuildID)))
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// cooldownLines gathers every cooldown that applies to the user in a guild.
+// New rate-limited features should add themselves here, and a label for their
+// kind to the cooldown template.
+func (m *module) cooldownLines(guildId, userId string) []view.CooldownLine {
+	var lines []view.CooldownLine
+
+	fishLine := view.CooldownLine{Kind: "fish"}
+	charges, capacity := m.fishLim.Remaining(guildId, userId)
+	fishLine.Charges, fishLine.Capacity = charges, capacity
+	if until, ok := m.fishLim.Peek(guildId, userId); ok {
+		if charges == 0 {
+			fishLine.Until = until.Unix()
+		} else {
+			fishLine.Next = until.Unix()
+		}
+	}
+	lines = append(lines, fishLine)
+
+	lbLine := view.CooldownLine{Kind: "leaderboard"}
+	if until, ok := m.lbLim.PeekGuild(guildId, "leaderboard"); ok {
+		lbLine.Until = until.Unix()
+	}
+	lines = append(lines, lbLine)
+
+	// /daily resets at the guild's midnight rather than after a fixed time
+	dailyLine := view.CooldownLine{Kind: "daily"}
+	gid, uid := toInt64(guildId), toInt64(userId)
+	now := time.Now().In(m.settings.location(gid))
+	if last, err := m.store.LastDailyClaim(context.TODO(), gid, uid); err != nil {
+		logREST("failed to load daily claim", err)
+	} else if last == fish.DayNumber(now, now.Location()) {
+		dailyLine.Until = nextMidnight(now).Unix()
+	}
+	lines = append(lines, dailyLine)
+
+	return lines
+}
+
+// interactionUserId returns the invoking user's id for both guild and DM
+// interactions
+func interactionUserId(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
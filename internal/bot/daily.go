@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// nextMidnight is when the next calendar day starts in now's location
+func nextMidnight(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
+
+// milestoneBait is the bait handed out on streak milestones: the priciest
+// bait in the catalog
+func (m *module) milestoneBait() (fish.Bait, bool) {
+	var best fish.Bait
+	for _, bt := range m.bait.All() {
+		if bt.Price > best.Price {
+			best = bt
+		}
+	}
+	return best, best.Key != ""
+}
+
+func (m *module) handleDaily(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/daily must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	guildId, userId := toInt64(i.GuildID), toInt64(interactionUserId(i))
+	loc := m.settings.location(guildId)
+	now := time.Now().In(loc)
+
+	active, err := m.store.ActiveSlots(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load catch times", err)
+		respondEphemeral(s, i, "Error loading your streak.")
+		return
+	}
+	streak := fish.CurrentStreak(active, now)
+
+	claim := store.DailyClaim{
+		Day:    fish.DayNumber(now, loc),
+		Streak: streak,
+		Coins:  fish.DailyReward(streak),
+	}
+	var bait fish.Bait
+	// Until they fish today the streak is still yesterday's, whose milestone
+	// was paid by yesterday's claim
+	if fish.IsStreakMilestone(streak) && fish.FishedToday(active, now) {
+		var ok bool
+		if bait, ok = m.milestoneBait(); ok {
+			claim.BaitKey = bait.Key
+			claim.BaitQty = bait.Pack * fish.DailyMilestonePack
+		}
+	}
+
+	err = m.store.ClaimDaily(context.TODO(), guildId, userId, claim)
+	if errors.Is(err, store.ErrAlreadyClaimed) {
+		respondEphemeral(s, i, fmt.Sprintf("You've already claimed today's reward - come back <t:%d:R>.", nextMidnight(now).Unix()))
+		return
+	}
+	if err != nil {
+		logREST("failed to claim daily", err)
+		respondEphemeral(s, i, "Error claiming your reward, try again later.")
+		return
+	}
+
+	balance, err := m.store.Balance(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load balance", err)
+	}
+
+	v := view.DailyView{
+		Coins:       claim.Coins,
+		Bait:        bait.Name,
+		BaitQty:     claim.BaitQty,
+		Streak:      streak,
+		MaxStreak:   streak >= fish.DailyMaxBonusDays,
+		StreakBonus: fish.DailyStreakBonus,
+		Balance:     balance,
+		Timezone:    loc.String(),
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{view.DailyEmbed(v, m.settings.templates(guildId))},
+		},
+	})
+}
@@ -0,0 +1,241 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// fightIdle is how long a fight waits for the next press before the fish
+// gets away
+const fightIdle = 30 * time.Second
+
+// fightSession is a huge fish on the line. Like a reeling cast, nothing is
+// stored until it's landed.
+type fightSession struct {
+	id          string
+	userId      string
+	cast        cast
+	interaction *discordgo.Interaction // the /fish command, whose response shows the fight
+
+	mu    sync.Mutex
+	fight *fish.Fight
+	timer *time.Timer
+}
+
+type fightSessions struct {
+	mu   sync.Mutex
+	open map[string]*fightSession
+}
+
+func newFightSessions() *fightSessions {
+	return &fightSessions{open: make(map[string]*fightSession)}
+}
+
+func (r *fightSessions) add(fs *fightSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.open[fs.id] = fs
+}
+
+func (r *fightSessions) get(id string) (*fightSession, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fs, ok := r.open[id]
+	return fs, ok
+}
+
+func (r *fightSessions) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.open, id)
+}
+
+// stop cancels every idle timeout
+func (r *fightSessions) stop() {
+	// finish locks r.mu while holding fs.mu, so never hold both the other way
+	r.mu.Lock()
+	open := make([]*fightSession, 0, len(r.open))
+	for _, fs := range r.open {
+		open = append(open, fs)
+	}
+	r.mu.Unlock()
+
+	for _, fs := range open {
+		fs.mu.Lock()
+		if fs.timer != nil {
+			fs.timer.Stop()
+		}
+		fs.mu.Unlock()
+	}
+}
+
+// finish drops the session. Callers hold fs.mu.
+func (r *fightSessions) finish(fs *fightSession) {
+	if fs.timer != nil {
+		fs.timer.Stop()
+	}
+	r.remove(fs.id)
+}
+
+// startFight hooks the cast's fish if the guild has fights on and it's big
+// enough to put one up. The caller shows the returned embed and buttons; a nil
+// session means the fish lands straight away.
+func (m *module) startFight(interaction *discordgo.Interaction, c cast) *fightSession {
+	if !m.settings.get(c.catch.GuildId).Fights {
+		return nil
+	}
+	sp, ok := m.reg.GetById(c.catch.SpeciesId)
+	if !ok || !fish.NeedsFight(fish.SizeClassFor(sp, c.catch.Size)) {
+		return nil
+	}
+
+	tier := m.picker.SpeciesTier(c.catch.SpeciesId)
+	fs := &fightSession{
+		id:          interaction.ID,
+		userId:      strconv.FormatInt(c.catch.UserId, 10),
+		cast:        c,
+		interaction: interaction,
+		fight:       fish.NewFight(tier, fish.SizePercentile(sp, c.catch.Size), c.lineStrength, nil),
+	}
+	m.fights.add(fs)
+	fs.mu.Lock()
+	fs.timer = time.AfterFunc(fightIdle, func() { m.fightTimeout(fs) })
+	fs.mu.Unlock()
+	return fs
+}
+
+// fightTimeout is the angler walking away from the rod
+func (m *module) fightTimeout(fs *fightSession) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.fight.State != fish.FightOn {
+		return
+	}
+	fs.fight.State = fish.FightSpooled
+	m.fights.finish(fs)
+
+	if _, err := m.s.InteractionResponseEdit(fs.interaction, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{m.fightLostEmbed(fs)},
+		Components: &[]discordgo.MessageComponent{},
+	}); err != nil {
+		logREST("edit failed", err)
+	}
+}
+
+func fightComponents(fs *fightSession) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Reel", Style: discordgo.SuccessButton, CustomID: "fight|" + fs.id + "|reel", Emoji: &discordgo.ComponentEmoji{Name: "🎣"}},
+			discordgo.Button{Label: "Give slack", Style: discordgo.SecondaryButton, CustomID: "fight|" + fs.id + "|slack", Emoji: &discordgo.ComponentEmoji{Name: "🪢"}},
+		}},
+	}
+}
+
+// fightEmbed shows a fight in progress
+func (m *module) fightEmbed(fs *fightSession, last fish.FightAction) *discordgo.MessageEmbed {
+	f := fs.fight
+	moment := "start"
+	if f.Steps > 0 {
+		switch {
+		case f.Surge >= f.Power*1.2:
+			moment = "run"
+		case f.Surge <= f.Power*0.7:
+			moment = "tiring"
+		case last == fish.FightSlack:
+			moment = "slack"
+		default:
+			moment = "reel"
+		}
+	}
+
+	return view.FightEmbed(view.FightView{
+		Angler:    fs.cast.username,
+		Moment:    moment,
+		Tension:   f.Tension,
+		Distance:  f.Distance,
+		MovesLeft: fish.FightMaxSteps - f.Steps,
+	}, m.settings.templates(fs.cast.catch.GuildId))
+}
+
+// fightLostEmbed says how the fish got away, and what it was
+func (m *module) fightLostEmbed(fs *fightSession) *discordgo.MessageEmbed {
+	var outcome string
+	switch fs.fight.State {
+	case fish.FightSnapped:
+		outcome = "snapped"
+	case fish.FightThrown:
+		outcome = "thrown"
+	default:
+		outcome = "spooled"
+	}
+
+	sp, _ := m.reg.GetById(fs.cast.catch.SpeciesId)
+	return view.FightLostEmbed(view.FightLostView{
+		Angler:    fs.cast.username,
+		Outcome:   outcome,
+		Species:   sp.Name,
+		Size:      fs.cast.catch.Size,
+		SizeClass: fish.SizeClassFor(sp, fs.cast.catch.Size).String(),
+	}, m.settings.templates(fs.cast.catch.GuildId))
+}
+
+// handleFightComponent handles the fight buttons ("fight|<session id>|reel" or
+// "fight|<session id>|slack")
+func (m *module) handleFightComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, "|")
+	if len(parts) != 3 {
+		respondEphemeral(s, i, "Unknown action.")
+		return
+	}
+	fs, ok := m.fights.get(parts[1])
+	if !ok {
+		respondEphemeral(s, i, "This fight is already over.")
+		return
+	}
+	if interactionUserId(i) != fs.userId {
+		respondEphemeral(s, i, "That's not your line!")
+		return
+	}
+
+	action := fish.FightReel
+	if parts[2] == "slack" {
+		action = fish.FightSlack
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.fight.State != fish.FightOn {
+		respondEphemeral(s, i, "This fight is already over.")
+		return
+	}
+
+	data := &discordgo.InteractionResponseData{Components: []discordgo.MessageComponent{}}
+	switch fs.fight.Step(action) {
+	case fish.FightOn:
+		fs.timer.Reset(fightIdle)
+		data.Embeds = []*discordgo.MessageEmbed{m.fightEmbed(fs, action)}
+		data.Components = fightComponents(fs)
+	case fish.FightLanded:
+		m.fights.finish(fs)
+		l := m.landCatch(fs.cast)
+		data.Embeds = []*discordgo.MessageEmbed{l.embed}
+		data.Components = l.components
+		data.Files = l.files
+	default:
+		m.fights.finish(fs)
+		data.Embeds = []*discordgo.MessageEmbed{m.fightLostEmbed(fs)}
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: data,
+	}); err != nil {
+		logREST("fight update failed", err)
+	}
+}
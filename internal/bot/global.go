@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// markdownEscaper escapes the characters Discord treats as formatting
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, `*`, `\*`, `_`, `\_`, `~`, `\~`, "`", "\\`", `|`, `\|`, `>`, `\>`, `#`, `\#`,
+)
+
+// displayName is what the guild shows for a user: their server nickname,
+// then their global display name, then their username. member may be nil.
+func displayName(member *discordgo.Member, user *discordgo.User) string {
+	if member != nil && member.Nick != "" {
+		return member.Nick
+	}
+	if user == nil {
+		return ""
+	}
+	if user.GlobalName != "" {
+		return user.GlobalName
+	}
+	return user.Username
+}
+
+// safeName makes a display name from another server safe to show: no
+// formatting, no mentions, and a sensible length. Global boards never use
+// <@id> mentions, since those would show raw ids (or ping) outside the guild.
+func safeName(name string) string {
+	if name == "" {
+		return "Anonymous angler"
+	}
+	if r := []rune(name); len(r) > 32 {
+		name = string(r[:32]) + "…"
+	}
+	name = markdownEscaper.Replace(name)
+	// A zero-width space after @ defuses @everyone, @here and role pings
+	return strings.ReplaceAll(name, "@", "@\u200b")
+}
+
+// offerGlobal puts a landed catch up for the global leaderboard
+func (m *module) offerGlobal(c fish.Catch, name string) {
+	if !m.globalBoard {
+		return
+	}
+	if err := m.store.OfferGlobalTop(context.TODO(), c, name); err != nil {
+		logREST("failed to update global leaderboard", err)
+	}
+}
+
+// globalLeaderboard edits the deferred /leaderboard response with the biggest
+// catches across every server that hasn't opted out
+func (m *module) globalLeaderboard(s *discordgo.Session, i *discordgo.InteractionCreate, speciesId fish.SpeciesId) {
+	if !m.globalBoard {
+		editResponseText(s, i, "The global leaderboard isn't enabled on this bot.")
+		return
+	}
+
+	v := view.LeaderboardView{Global: true}
+	scope := int64(store.GlobalOverall)
+	if speciesId >= 0 {
+		scope = int64(speciesId)
+		if sp, ok := m.reg.GetById(speciesId); ok {
+			v.Species = sp.Name
+		}
+	}
+
+	entries, err := m.store.GlobalTop(context.TODO(), scope, 10)
+	if err != nil {
+		editResponseText(s, i, "Error loading leaderboard.")
+		logREST("failed to load global leaderboard", err)
+		return
+	}
+	if len(entries) == 0 {
+		editResponseText(s, i, "No catches on the global leaderboard yet!")
+		return
+	}
+
+	for idx, e := range entries {
+		sp, _ := m.reg.GetById(e.SpeciesId)
+		v.Rows = append(v.Rows, view.LeaderboardRow{
+			Pos:       idx + 1,
+			Size:      e.Size,
+			SizeClass: fish.SizeClassFor(sp, e.Size).String(),
+			Angler:    safeName(e.Name),
+			Species:   sp.Name,
+		})
+	}
+
+	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:          &[]*discordgo.MessageEmbed{view.LeaderboardEmbed(v, m.settings.templates(toInt64(i.GuildID)))},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
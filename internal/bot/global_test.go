@@ -0,0 +1,45 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDisplayName(t *testing.T) {
+	user := &discordgo.User{Username: "ahab1851", GlobalName: "Captain Ahab"}
+	tests := []struct {
+		name   string
+		member *discordgo.Member
+		user   *discordgo.User
+		want   string
+	}{
+		{"nickname first", &discordgo.Member{Nick: "The Captain"}, user, "The Captain"},
+		{"then global name", &discordgo.Member{}, user, "Captain Ahab"},
+		{"no member", nil, user, "Captain Ahab"},
+		{"then username", nil, &discordgo.User{Username: "ahab1851"}, "ahab1851"},
+		{"nothing known", nil, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := displayName(tt.member, tt.user); got != tt.want {
+				t.Errorf("displayName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", "Anonymous angler"},
+		{"Ahab", "Ahab"},
+		{"*bold* _it_", `\*bold\* \_it\_`},
+		{"@everyone", "@\u200beveryone"},
+		{"abcdefghijklmnopqrstuvwxyz0123456789", "abcdefghijklmnopqrstuvwxyz012345…"},
+	}
+	for _, tt := range tests {
+		if got := safeName(tt.in); got != tt.want {
+			t.Errorf("safeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -12,39 +12,77 @@ import (
 	"github.com/faideww/chat-fishing/internal/fish"
 	"github.com/faideww/chat-fishing/internal/ratelimit"
 	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
 )
 
 type module struct {
-	s          *discordgo.Session
-	appId      string
-	scopeGuild string
-	picker     *fish.Picker
-	reg        *fish.Registry
-	fishLim    *ratelimit.Limiter
-	lbLim      *ratelimit.Limiter
-	store      *store.SQLiteStore
+	s            *discordgo.Session
+	appId        string
+	scopeGuild   string
+	picker       *fish.Picker
+	reg          *fish.Registry
+	gear         *fish.GearCatalog
+	bait         *fish.BaitCatalog
+	achievements *fish.AchievementCatalog
+	quests       *fish.QuestCatalog
+	fishLim      ratelimit.Gate
+	lbLim        ratelimit.Gate
+	store        *store.SQLiteStore
+	settings     *guildSettings
+	market       *marketPrices
+	trades       *tradeSessions
+	reels        *reelSessions
+	fights       *fightSessions
+	weather      *fish.WeatherSim
+	globalBoard  bool
+	profiles     *profileStats
 }
 
-func Setup(
-	session *discordgo.Session,
-	appId, scopeGuild string,
-	reg *fish.Registry,
-	store *store.SQLiteStore,
-	fishLim *ratelimit.Limiter,
-	lbLim *ratelimit.Limiter,
-) (func(), error) {
+// Deps is everything the bot needs from main
+type Deps struct {
+	Registry     *fish.Registry
+	Gear         *fish.GearCatalog
+	Bait         *fish.BaitCatalog
+	Achievements *fish.AchievementCatalog
+	Quests       *fish.QuestCatalog
+	Store        *store.SQLiteStore
+	FishLim      ratelimit.Gate
+	LbLim        ratelimit.Gate
+	Clock        ratelimit.Clock // time source for the weather; nil is the real time
+	GlobalMarket bool            // price fish from sales across every guild
+	GlobalBoard  bool            // keep a leaderboard across every guild that hasn't opted out
+}
 
-	picker := fish.NewPicker(reg, nil)
+func Setup(session *discordgo.Session, appId, scopeGuild string, deps Deps) (func(), error) {
+	picker := fish.NewPicker(deps.Registry, nil)
 	m := &module{
-		s:          session,
-		appId:      appId,
-		scopeGuild: scopeGuild,
-		picker:     picker,
-		reg:        reg,
-		store:      store,
-		fishLim:    fishLim,
-		lbLim:      lbLim,
+		s:            session,
+		appId:        appId,
+		scopeGuild:   scopeGuild,
+		picker:       picker,
+		reg:          deps.Registry,
+		gear:         deps.Gear,
+		bait:         deps.Bait,
+		achievements: deps.Achievements,
+		quests:       deps.Quests,
+		store:        deps.Store,
+		fishLim:      deps.FishLim,
+		lbLim:        deps.LbLim,
+		settings:     newGuildSettings(deps.Store),
+		market:       newMarketPrices(deps.Store, picker, deps.Registry, deps.GlobalMarket),
+		trades:       newTradeSessions(),
+		reels:        newReelSessions(),
+		fights:       newFightSessions(),
+		weather:      fish.NewWeatherSim(deps.Clock),
+		globalBoard:  deps.GlobalBoard,
+		profiles:     newProfileStats(deps.Store),
 	}
+	// Trades the last run left open too recently to expire now are caught by
+	// a second pass once they're overdue
+	expireStaleTrades(deps.Store)
+	staleTrades := time.AfterFunc(tradeStaleAfter, func() { expireStaleTrades(deps.Store) })
+	fishLim, lbLim := deps.FishLim, deps.LbLim
+	fishLim.SetResolver(ratelimit.ResolverFunc(m.settings.fishingCooldown))
 
 	cmds := commandDefs()
 
@@ -57,21 +95,125 @@ func Setup(
 		fmt.Printf("command active: %s (%s)\n", c.Name, c.Description)
 	}
 
-	session.AddHandler(m.onInteraction)
-
-	return func() {}, nil
+	removeHandler := session.AddHandler(m.onInteraction)
+	stopAuctions := m.startAuctions()
+	stopTournaments := m.startTournaments()
+
+	return func() {
+		removeHandler()
+		staleTrades.Stop()
+		stopAuctions()
+		stopTournaments()
+		m.trades.stop()
+		m.reels.stop()
+		m.fights.stop()
+		fishLim.Stop()
+		lbLim.Stop()
+
+		for name, lim := range map[string]ratelimit.Gate{"fishing": fishLim, "leaderboard": lbLim} {
+			st := lim.Stats()
+			log.Printf("limiter %s: %d keys tracked, %d evicted", name, st.TrackedKeys, st.Evictions)
+		}
+	}, nil
 }
 
 func (m *module) onInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if i.Type != discordgo.InteractionApplicationCommand {
-		return
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		m.onCommand(s, i)
+	case discordgo.InteractionMessageComponent:
+		m.onComponent(s, i)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		m.onAutocomplete(s, i)
+	case discordgo.InteractionModalSubmit:
+		m.onModal(s, i)
 	}
+}
 
+func (m *module) onAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.ApplicationCommandData().Name {
+	case "buy":
+		m.handleBuyAutocomplete(s, i)
+	case "fish":
+		m.handleFishAutocomplete(s, i)
+	case "titles":
+		m.handleTitlesAutocomplete(s, i)
+	case "conservation":
+		m.handleConservationAutocomplete(s, i)
+	case "team":
+		m.handleTeamAutocomplete(s, i)
+	case "config":
+		m.handleConfigAutocomplete(s, i)
+	}
+}
+
+// onComponent routes button and select menu presses by the prefix of their
+// custom id ("<feature>|...")
+func (m *module) onComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	prefix, _, _ := strings.Cut(i.MessageComponentData().CustomID, "|")
+	switch prefix {
+	case "inv":
+		m.handleInventoryComponent(s, i)
+	case "trade":
+		m.handleTradeComponent(s, i)
+	case "reel":
+		m.handleReelComponent(s, i)
+	case "fight":
+		m.handleFightComponent(s, i)
+	case "release":
+		m.handleReleaseComponent(s, i)
+	}
+}
+
+// onModal routes modal submissions the same way as onComponent
+func (m *module) onModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	prefix, _, _ := strings.Cut(i.ModalSubmitData().CustomID, "|")
+	switch prefix {
+	case "trade":
+		m.handleTradeModal(s, i)
+	}
+}
+
+func (m *module) onCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	switch i.ApplicationCommandData().Name {
 	case "fish":
 		m.handleFish(s, i)
 	case "leaderboard":
 		m.handleLeaderboard(s, i)
+	case "config":
+		m.handleConfig(s, i)
+	case "cooldown":
+		m.handleCooldown(s, i)
+	case "sell":
+		m.handleSell(s, i)
+	case "inventory":
+		m.handleInventory(s, i)
+	case "shop":
+		m.handleShop(s, i)
+	case "buy":
+		m.handleBuy(s, i)
+	case "market":
+		m.handleMarket(s, i)
+	case "trade":
+		m.handleTrade(s, i)
+	case "auction":
+		m.handleAuction(s, i)
+	case "titles":
+		m.handleTitles(s, i)
+	case "conservation":
+		m.handleConservation(s, i)
+	case "tournament":
+		m.handleTournament(s, i)
+	case "team":
+		m.handleTeam(s, i)
+	case "quests":
+		m.handleQuests(s, i)
+	case "daily":
+		m.handleDaily(s, i)
+	case "forecast":
+		m.handleForecast(s, i)
+	case "profile":
+		m.handleProfile(s, i)
 	}
 }
 
@@ -82,19 +224,59 @@ func (m *module) handleFish(s *discordgo.Session, i *discordgo.InteractionCreate
 		return
 	}
 
-	userIdStr := ""
-	if i.Member != nil && i.Member.User != nil {
-		userIdStr = i.Member.User.ID
-	} else if i.User != nil {
-		userIdStr = i.User.ID
+	userIdStr := interactionUserId(i)
+	guildId, userId := toInt64(i.GuildID), toInt64(userIdStr)
+	mods := m.loadoutModifiers(guildId, userId)
+
+	// Optional bait: validate before spending the cooldown on it
+	var (
+		bait     fish.Bait
+		baitLeft int
+	)
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name != "bait" {
+			continue
+		}
+		key := opt.StringValue()
+		var ok bool
+		if bait, ok = m.bait.Get(key); !ok {
+			respondEphemeral(s, i, fmt.Sprintf("Unknown bait '%s' - see `/shop`.", key))
+			return
+		}
+		counts, err := m.store.BaitCounts(context.TODO(), guildId, userId)
+		if err != nil {
+			logREST("failed to load bait", err)
+		}
+		if baitLeft = counts[bait.Key]; baitLeft == 0 {
+			respondEphemeral(s, i, fmt.Sprintf("You're out of **%s** - buy more with `/buy`.", bait.Name))
+			return
+		}
 	}
 
 	// Rate limiting
-	if ok, rem := m.fishLim.Try(i.GuildID, userIdStr); !ok {
+	if ok, rem := m.fishLim.TryScaled(i.GuildID, userIdStr, mods.CooldownScale()); !ok {
 		respondEphemeral(s, i, fmt.Sprintf("⏳ You’re reeling in… try again in %s.", pretty(rem)))
 		return
 	}
 
+	// Bait is only used up once the cast actually happens
+	if bait.Key != "" {
+		used, err := m.store.ConsumeBait(context.TODO(), guildId, userId, bait.Key)
+		if err != nil {
+			logREST("failed to consume bait", err)
+		}
+		if used {
+			mods = bait.With(mods)
+			baitLeft--
+		} else {
+			bait = fish.Bait{}
+		}
+	}
+
+	// The guild's weather favours some kinds of fish
+	weather := m.weather.Current(guildId).Weather
+	mods = mods.WithTagBoosts(weather.Boosts())
+
 	// Send a deferred ack so we don't hit a timeout while processing
 	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
@@ -104,52 +286,120 @@ func (m *module) handleFish(s *discordgo.Session, i *discordgo.InteractionCreate
 	}
 
 	// Roll fish
-	catchId := m.picker.PickId()
-	sz := m.picker.RollSize(catchId)
-
-	err := m.store.Add(context.TODO(), fish.Catch{
-		GuildId:   toInt64(i.GuildID),
-		UserId:    toInt64(userIdStr),
-		SpeciesId: catchId,
-		Size:      sz,
-		CaughtAt:  time.Now(),
-	})
+	catchId := m.picker.PickIdWith(mods)
+	sz := m.picker.RollSizeWith(catchId, mods)
 
+	name := displayName(i.Member, i.Member.User)
+
+	c := cast{
+		catch: fish.Catch{
+			GuildId:   guildId,
+			UserId:    userId,
+			SpeciesId: catchId,
+			Size:      sz,
+		},
+		username:     withTitle(name, m.titleFor(guildId, userId)),
+		name:         name,
+		bait:         bait,
+		baitLeft:     baitLeft,
+		weather:      weather,
+		lineStrength: mods.LineStrength,
+	}
+	if m.settings.get(guildId).Reeling {
+		m.startReel(s, i, c)
+		return
+	}
+
+	edit := &discordgo.WebhookEdit{}
+	if fs := m.startFight(i.Interaction, c); fs != nil {
+		components := fightComponents(fs)
+		edit.Embeds = &[]*discordgo.MessageEmbed{m.fightEmbed(fs, fish.FightReel)}
+		edit.Components = &components
+	} else {
+		l := m.landCatch(c)
+		edit.Embeds = &[]*discordgo.MessageEmbed{l.embed}
+		edit.Components = &l.components
+		edit.Files = l.files
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		logREST("edit failed", err)
+	}
+}
+
+// cast is a rolled fish that hasn't been landed yet
+type cast struct {
+	catch    fish.Catch
+	username string // display name including title
+	name     string // plain display name, for showing outside the guild
+	bait     fish.Bait
+	baitLeft int
+	weather  fish.Weather
+
+	lineStrength float64 // from the loadout, for fights
+}
+
+// landed is the message showing a landed catch
+type landed struct {
+	embed      *discordgo.MessageEmbed
+	components []discordgo.MessageComponent
+	files      []*discordgo.File
+}
+
+// landCatch records the cast's fish and builds the catch message: the embed,
+// its catch card, and a Release button once the catch is stored
+func (m *module) landCatch(c cast) landed {
+	c.catch.CaughtAt = time.Now()
+	outcome, err := m.recordCatch(c.catch, c.name)
 	if err != nil {
 		logREST("failed to insert", err)
 	}
 
+	catchId, sz := c.catch.SpeciesId, c.catch.Size
 	tier := m.picker.SpeciesTier(catchId)
 	sp, _ := m.reg.GetById(catchId)
 	szClass := fish.SizeClassFor(sp, sz)
 
-	username := i.Member.Nick
-	if username == "" {
-		username = i.Member.User.Username
-	}
-
 	// TODO: some words beginning with consonants use 'an' (hour, heir, honest).
 	indefArticle := "a"
 	if sp.Name[0] == 'a' || sp.Name[0] == 'e' || sp.Name[0] == 'i' || sp.Name[0] == 'o' || sp.Name[0] == 'u' {
 		indefArticle = "an"
 	}
 
-	thumb := m.reg.EmbedThumb(fish.SpeciesId(sp.Id))
-	embed := &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("%s caught %s %s!", username, indefArticle, sp.Name),
-		Description: fmt.Sprintf("Size: **%.1f cm**  ·  **%s**\nRarity: **%s**", sz, szClass.String(), tier.String()),
-		Color:       fish.ColorForTier(tier),
-		Thumbnail:   thumb,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Tip: Bigger fish are rarer!",
-		},
+	v := view.CatchView{
+		Angler:       c.username,
+		Species:      sp.Name,
+		Article:      indefArticle,
+		Size:         sz,
+		SizeClass:    szClass.String(),
+		Tier:         tier.String(),
+		Color:        fish.ColorForTier(tier),
+		ThumbURL:     sp.Image,
+		CatchId:      outcome.id,
+		Streak:       outcome.streak,
+		Weather:      c.weather.String(),
+		WeatherEmoji: c.weather.Emoji(),
+		Milestone:    outcome.newDay && fish.IsStreakMilestone(outcome.streak),
+		Quests:       m.questViews(outcome.quests),
+	}
+	if c.bait.Key != "" {
+		v.Bait, v.BaitLeft = c.bait.Name, c.baitLeft
+	}
+	guildIdStr, userIdStr := strconv.FormatInt(c.catch.GuildId, 10), strconv.FormatInt(c.catch.UserId, 10)
+	v.Charges, v.Capacity = m.fishLim.Remaining(guildIdStr, userIdStr)
+	for _, a := range outcome.unlocked {
+		v.Achievements = append(v.Achievements, view.AchievementView{Name: a.Name, Description: a.Description, Title: a.Title})
 	}
 
-	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{embed},
-	}); err != nil {
-		logREST("edit failed", err)
+	out := landed{components: []discordgo.MessageComponent{}}
+	if outcome.id > 0 {
+		out.components = releaseComponents(outcome.id, fish.ReleasePoints(tier, szClass))
+	}
+	if card := m.catchCard(c, sp, tier); card != nil {
+		v.CardName = card.Name
+		out.files = []*discordgo.File{card}
 	}
+	out.embed = view.CatchEmbed(v, m.settings.templates(c.catch.GuildId))
+	return out
 }
 
 func (m *module) handleLeaderboard(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -160,7 +410,7 @@ func (m *module) handleLeaderboard(s *discordgo.Session, i *discordgo.Interactio
 	}
 
 	// Rate limiting
-	if ok, rem := m.lbLim.Try(i.GuildID, "leaderboard"); !ok {
+	if ok, rem := m.lbLim.TryGuild(i.GuildID, "leaderboard"); !ok {
 		respondEphemeral(s, i, fmt.Sprintf("⏳ Leaderboard refreshing... try again in %s.", pretty(rem)))
 		return
 	}
@@ -176,8 +426,13 @@ func (m *module) handleLeaderboard(s *discordgo.Session, i *discordgo.Interactio
 	data := i.ApplicationCommandData()
 	speciesId := fish.SpeciesId(-1)
 	limit := 10
+	var scope, period string
 	for _, opt := range data.Options {
 		switch opt.Name {
+		case "scope":
+			scope = opt.StringValue()
+		case "period":
+			period = opt.StringValue()
 		case "species":
 			fishKey := opt.StringValue()
 			var ok bool
@@ -189,6 +444,15 @@ func (m *module) handleLeaderboard(s *discordgo.Session, i *discordgo.Interactio
 		}
 	}
 
+	switch scope {
+	case "team":
+		m.teamLeaderboard(s, i, period)
+		return
+	case "global":
+		m.globalLeaderboard(s, i, speciesId)
+		return
+	}
+
 	var rows []fish.Catch
 	if speciesId >= 0 {
 		rs, err := m.store.TopBySizeGuildSpecies(context.TODO(), toInt64(i.GuildID), speciesId, limit)
@@ -213,33 +477,32 @@ func (m *module) handleLeaderboard(s *discordgo.Session, i *discordgo.Interactio
 		return
 	}
 
-	desc := strings.Builder{}
-
+	userIds := make([]int64, len(rows))
 	for idx, c := range rows {
-		pos := idx + 1
-		// mention format: <@USERID>
-		uid := fmt.Sprintf("%d", c.UserId)
-		sp, _ := m.reg.GetById(fish.SpeciesId(c.SpeciesId))
-		szClass := fish.SizeClassFor(sp, c.Size)
-		line := fmt.Sprintf("**#%d** **%.1f cm (%s)** — <@%s> — %s\n",
-			pos, c.Size, szClass.String(), uid, sp.Name)
-		desc.WriteString(line)
+		userIds[idx] = c.UserId
 	}
+	titles := m.titlesFor(toInt64(i.GuildID), userIds)
 
-	embed := &discordgo.MessageEmbed{
-		Title:       "🏆 Leaderboard - Biggest Catches",
-		Description: desc.String(),
-		Color:       0xf1c40f,
+	var v view.LeaderboardView
+	if sp, ok := m.reg.GetById(speciesId); ok && speciesId >= 0 {
+		v.Species = sp.Name
 	}
-
-	if speciesId >= 0 {
-		if sp, ok := m.reg.GetById(fish.SpeciesId(speciesId)); ok {
-			embed.Title = fmt.Sprintf("🏆 Leaderboard — %s", sp.Name)
-		}
+	for idx, c := range rows {
+		sp, _ := m.reg.GetById(fish.SpeciesId(c.SpeciesId))
+		v.Rows = append(v.Rows, view.LeaderboardRow{
+			Pos:       idx + 1,
+			Size:      c.Size,
+			SizeClass: fish.SizeClassFor(sp, c.Size).String(),
+			// mention format: <@USERID>
+			Angler:   withTitle(fmt.Sprintf("<@%d>", c.UserId), titles[c.UserId]),
+			Species:  sp.Name,
+			Released: c.Released,
+		})
+		v.AnyReleased = v.AnyReleased || c.Released
 	}
 
 	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{embed},
+		Embeds: &[]*discordgo.MessageEmbed{view.LeaderboardEmbed(v, m.settings.templates(toInt64(i.GuildID)))},
 	})
 }
 
@@ -258,12 +521,16 @@ func editResponseText(s *discordgo.Session, i *discordgo.InteractionCreate, cont
 }
 
 func pretty(d time.Duration) string {
-	// mm:ss
+	// mm:ss, or h:mm:ss once guild cooldowns reach an hour or more
 	if d < 0 {
 		d = 0
 	}
-	m := int(d / time.Minute)
+	h := int(d / time.Hour)
+	m := int((d % time.Hour) / time.Minute)
 	s := int((d % time.Minute) / time.Second)
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
 	return fmt.Sprintf("%d:%02d", m, s)
 }
 
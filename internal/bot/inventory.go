@@ -0,0 +1,292 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+const inventoryPageSize = 10
+
+// invState is everything needed to redraw an inventory page. It round-trips
+// through component custom ids, so no server-side session is needed.
+type invState struct {
+	owner   string
+	species fish.SpeciesId // -1 for any
+	tier    int            // -1 for any
+	size    int            // -1 for any
+	sort    string         // "new", "big" or "value"
+	page    int
+}
+
+func (st invState) encode(action string) string {
+	return fmt.Sprintf("inv|%s|%s|%d|%d|%d|%s|%d",
+		action, st.owner, st.species, st.tier, st.size, st.sort, st.page)
+}
+
+func decodeInvState(customId string) (string, invState, bool) {
+	parts := strings.Split(customId, "|")
+	if len(parts) != 8 || parts[0] != "inv" {
+		return "", invState{}, false
+	}
+	species, err1 := strconv.Atoi(parts[3])
+	tier, err2 := strconv.Atoi(parts[4])
+	size, err3 := strconv.Atoi(parts[5])
+	page, err4 := strconv.Atoi(parts[7])
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return "", invState{}, false
+	}
+	return parts[1], invState{
+		owner:   parts[2],
+		species: fish.SpeciesId(species),
+		tier:    tier,
+		size:    size,
+		sort:    parts[6],
+		page:    page,
+	}, true
+}
+
+type invRow struct {
+	c     fish.Catch
+	sp    fish.Species
+	class fish.SizeClass
+	tier  fish.RarityTier
+	value int64
+}
+
+func (m *module) handleInventory(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/inventory must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	st := invState{owner: interactionUserId(i), species: -1, tier: -1, size: -1, sort: "new"}
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "species":
+			fishKey := opt.StringValue()
+			id, ok := m.reg.IdByKey(fishKey)
+			if !ok {
+				respondEphemeral(s, i, fmt.Sprintf("Unknown fish '%s'", fishKey))
+				return
+			}
+			st.species = id
+		case "tier":
+			st.tier, _ = strconv.Atoi(opt.StringValue())
+		case "size":
+			st.size, _ = strconv.Atoi(opt.StringValue())
+		case "sort":
+			st.sort = opt.StringValue()
+		}
+	}
+
+	embed, components, err := m.renderInventory(i.GuildID, st)
+	if err != nil {
+		logREST("failed to load inventory", err)
+		respondEphemeral(s, i, "Error loading your inventory.")
+		return
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func (m *module) handleInventoryComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+	action, st, ok := decodeInvState(data.CustomID)
+	if !ok {
+		return
+	}
+	if st.owner != interactionUserId(i) {
+		respondEphemeral(s, i, "This isn't your inventory - use `/inventory` to see yours.")
+		return
+	}
+
+	value := ""
+	if len(data.Values) > 0 {
+		value = data.Values[0]
+	}
+	switch action {
+	case "page":
+		// page already encoded in the button's state
+	case "sort":
+		st.sort, st.page = value, 0
+	case "tier":
+		st.tier, _ = strconv.Atoi(value)
+		st.page = 0
+	case "size":
+		st.size, _ = strconv.Atoi(value)
+		st.page = 0
+	}
+
+	embed, components, err := m.renderInventory(i.GuildID, st)
+	if err != nil {
+		logREST("failed to load inventory", err)
+		return
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
+
+func (m *module) inventoryRow(c fish.Catch, factors map[fish.SpeciesId]float64) invRow {
+	sp, _ := m.reg.GetById(c.SpeciesId)
+	return invRow{
+		c:     c,
+		sp:    sp,
+		class: fish.SizeClassFor(sp, c.Size),
+		tier:  m.picker.SpeciesTier(c.SpeciesId),
+		value: m.priceOf(c, factors),
+	}
+}
+
+// inventoryPage loads the rows on st's page, with the count and value of
+// every catch that matches. Newest and biggest first are paged in the store;
+// rarity and size class filters and sorting by value depend on the species
+// data and market, so those views load every catch and page here.
+func (m *module) inventoryPage(guildId string, st invState) (page []invRow, count int, total int64, err error) {
+	gid, owner := toInt64(guildId), toInt64(st.owner)
+	factors := m.market.factors(gid)
+
+	if st.tier < 0 && st.size < 0 && st.sort != "value" {
+		sizes, err := m.store.UnsoldSizes(context.TODO(), gid, owner, st.species)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		for _, sc := range sizes {
+			count += sc.N
+			total += int64(sc.N) * m.priceOf(fish.Catch{SpeciesId: sc.SpeciesId, Size: sc.Size}, factors)
+		}
+		catches, err := m.store.UnsoldPage(context.TODO(), gid, owner, st.species, st.sort == "big",
+			inventoryPageSize, clampPage(st.page, count)*inventoryPageSize)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		for _, c := range catches {
+			page = append(page, m.inventoryRow(c, factors))
+		}
+		return page, count, total, nil
+	}
+
+	catches, err := m.store.UnsoldCatches(context.TODO(), gid, owner, st.species)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	rows := make([]invRow, 0, len(catches))
+	for _, c := range catches {
+		r := m.inventoryRow(c, factors)
+		if st.tier >= 0 && int(r.tier) != st.tier {
+			continue
+		}
+		if st.size >= 0 && int(r.class) != st.size {
+			continue
+		}
+		rows = append(rows, r)
+		total += r.value
+	}
+
+	switch st.sort {
+	case "big":
+		sort.SliceStable(rows, func(a, b int) bool { return rows[a].c.Size > rows[b].c.Size })
+	case "value":
+		sort.SliceStable(rows, func(a, b int) bool { return rows[a].value > rows[b].value })
+	default:
+		// store returns newest first
+	}
+	start := clampPage(st.page, len(rows)) * inventoryPageSize
+	return rows[start:min(start+inventoryPageSize, len(rows))], len(rows), total, nil
+}
+
+// inventoryPages is how many pages count catches fill; always at least one
+func inventoryPages(count int) int {
+	return max((count+inventoryPageSize-1)/inventoryPageSize, 1)
+}
+
+// clampPage keeps page within the pages count catches fill
+func clampPage(page, count int) int {
+	return min(max(page, 0), inventoryPages(count)-1)
+}
+
+func (m *module) renderInventory(guildId string, st invState) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	rows, count, total, err := m.inventoryPage(guildId, st)
+	if err != nil {
+		return nil, nil, err
+	}
+	pages := inventoryPages(count)
+	st.page = clampPage(st.page, count)
+
+	v := view.InventoryView{Page: st.page + 1, Pages: pages, Count: count, Total: total}
+	if st.species >= 0 {
+		v.Species = m.reg.NameById(st.species)
+	}
+	for _, r := range rows {
+		v.Rows = append(v.Rows, view.InventoryRow{
+			Id:        r.c.Id,
+			Species:   r.sp.Name,
+			Size:      r.c.Size,
+			SizeClass: r.class.String(),
+			Tier:      r.tier.String(),
+			Value:     r.value,
+		})
+	}
+	embed := view.InventoryEmbed(v, m.settings.templates(toInt64(guildId)))
+
+	sortOpts := []discordgo.SelectMenuOption{
+		{Label: "Newest first", Value: "new", Default: st.sort == "new"},
+		{Label: "Biggest first", Value: "big", Default: st.sort == "big"},
+		{Label: "Most valuable first", Value: "value", Default: st.sort == "value"},
+	}
+
+	tierOpts := []discordgo.SelectMenuOption{{Label: "Any rarity", Value: "-1", Default: st.tier < 0}}
+	for t := fish.TierCommon; t <= fish.TierMythic; t++ {
+		tierOpts = append(tierOpts, discordgo.SelectMenuOption{
+			Label: t.String(), Value: strconv.Itoa(int(t)), Default: st.tier == int(t),
+		})
+	}
+
+	sizeOpts := []discordgo.SelectMenuOption{{Label: "Any size", Value: "-1", Default: st.size < 0}}
+	for c := fish.SizeTiny; c <= fish.SizeEnormous; c++ {
+		sizeOpts = append(sizeOpts, discordgo.SelectMenuOption{
+			Label: c.String(), Value: strconv.Itoa(int(c)), Default: st.size == int(c),
+		})
+	}
+
+	prev, next := st, st
+	prev.page, next.page = st.page-1, st.page+1
+
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{CustomID: st.encode("sort"), Placeholder: "Sort", Options: sortOpts},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{CustomID: st.encode("tier"), Placeholder: "Rarity", Options: tierOpts},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{CustomID: st.encode("size"), Placeholder: "Size", Options: sizeOpts},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "◀ Prev", Style: discordgo.SecondaryButton, CustomID: prev.encode("page"), Disabled: st.page == 0},
+			discordgo.Button{Label: "Next ▶", Style: discordgo.SecondaryButton, CustomID: next.encode("page"), Disabled: st.page >= pages-1},
+		}},
+	}
+
+	return embed, components, nil
+}
@@ -0,0 +1,221 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+const marketCacheTTL = time.Minute
+
+// marketPrices caches the per-species market factors for each market (a
+// guild, or 0 for the global market) and snapshots each day's prices to the
+// store for the trend lines.
+type marketPrices struct {
+	store  *store.SQLiteStore
+	picker *fish.Picker
+	reg    *fish.Registry
+	global bool
+
+	mu    sync.Mutex
+	cache map[int64]marketSnapshot
+	saved map[int64]int64 // market -> last day with a snapshot in the store
+}
+
+type marketSnapshot struct {
+	at      time.Time
+	factors map[fish.SpeciesId]float64
+}
+
+func newMarketPrices(st *store.SQLiteStore, picker *fish.Picker, reg *fish.Registry, global bool) *marketPrices {
+	return &marketPrices{
+		store:  st,
+		picker: picker,
+		reg:    reg,
+		global: global,
+		cache:  make(map[int64]marketSnapshot),
+		saved:  make(map[int64]int64),
+	}
+}
+
+func (mp *marketPrices) marketId(guildId int64) int64 {
+	if mp.global {
+		return 0
+	}
+	return guildId
+}
+
+// factors returns today's price multiplier for every species. Errors fall
+// back to base prices so the market never blocks selling.
+func (mp *marketPrices) factors(guildId int64) map[fish.SpeciesId]float64 {
+	id := mp.marketId(guildId)
+
+	mp.mu.Lock()
+	snap, ok := mp.cache[id]
+	mp.mu.Unlock()
+	if ok && time.Since(snap.at) < marketCacheTTL {
+		return snap.factors
+	}
+
+	now := time.Now()
+	today := store.Day(now)
+	sales, err := mp.store.SalesByDay(context.TODO(), id, today-fish.MarketWindowDays+1)
+	if err != nil {
+		log.Printf("failed to load market sales: %v", err)
+		return map[fish.SpeciesId]float64{}
+	}
+
+	factors := make(map[fish.SpeciesId]float64, mp.reg.Count())
+	for _, sp := range mp.reg.All() {
+		daily := make([]int64, fish.MarketWindowDays)
+		for day, n := range sales[sp.Id] {
+			if age := today - day; age >= 0 && age < fish.MarketWindowDays {
+				daily[age] = n
+			}
+		}
+		factors[sp.Id] = fish.MarketFactor(mp.picker.SpeciesTier(sp.Id), fish.Supply(daily))
+	}
+
+	mp.mu.Lock()
+	mp.cache[id] = marketSnapshot{at: now, factors: factors}
+	mp.mu.Unlock()
+	return factors
+}
+
+// price is what an average-sized catch of the species sells for at factor
+func (mp *marketPrices) price(id fish.SpeciesId, factor float64) int64 {
+	return fish.MarketPrice(mp.picker.SpeciesTier(id), 0.5, factor)
+}
+
+// snapshot records today's prices for the trend lines. The first snapshot of
+// a day stands, so the store is written at most once per market per day
+// rather than whenever the factors are recomputed.
+func (mp *marketPrices) snapshot(guildId int64) {
+	id, today := mp.marketId(guildId), store.Day(time.Now())
+
+	mp.mu.Lock()
+	done := mp.saved[id] == today
+	mp.mu.Unlock()
+	if done {
+		return
+	}
+
+	factors := mp.factors(guildId)
+	if len(factors) == 0 {
+		return // the sales didn't load; try again next time
+	}
+	prices := make(map[fish.SpeciesId]int64, len(factors))
+	for sp, f := range factors {
+		prices[sp] = mp.price(sp, f)
+	}
+	if err := mp.store.SavePrices(context.TODO(), id, today, prices); err != nil {
+		log.Printf("failed to save market prices: %v", err)
+		return
+	}
+
+	mp.mu.Lock()
+	mp.saved[id] = today
+	mp.mu.Unlock()
+}
+
+func (mp *marketPrices) invalidate(guildId int64) {
+	mp.mu.Lock()
+	delete(mp.cache, mp.marketId(guildId))
+	mp.mu.Unlock()
+}
+
+type marketRow struct {
+	sp      fish.Species
+	base    int64
+	history []int64 // oldest first, today last
+}
+
+func (m *module) handleMarket(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/market must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	speciesId := fish.SpeciesId(-1)
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "species" {
+			fishKey := opt.StringValue()
+			var ok bool
+			speciesId, ok = m.reg.IdByKey(fishKey)
+			if !ok {
+				respondEphemeral(s, i, fmt.Sprintf("Unknown fish '%s'", fishKey))
+				return
+			}
+		}
+	}
+
+	guildId := toInt64(i.GuildID)
+	m.market.snapshot(guildId)
+	factors := m.market.factors(guildId)
+
+	today := store.Day(time.Now())
+	history, err := m.store.PriceHistory(context.TODO(), m.market.marketId(guildId), today-fish.MarketWindowDays+1)
+	if err != nil {
+		logREST("failed to load price history", err)
+		respondEphemeral(s, i, "Error loading the market.")
+		return
+	}
+
+	var rows []marketRow
+	for _, sp := range m.reg.All() {
+		if speciesId >= 0 && sp.Id != speciesId {
+			continue
+		}
+		base := m.market.price(sp.Id, 1)
+
+		// Days without a snapshot carry the previous price forward; today's
+		// snapshot is only the opening price, so today shows the live one
+		vals := make([]int64, 0, fish.MarketWindowDays)
+		last := base
+		for day := today - fish.MarketWindowDays + 1; day < today; day++ {
+			if p, ok := history[sp.Id][day]; ok {
+				last = p
+			}
+			vals = append(vals, last)
+		}
+		if f, ok := factors[sp.Id]; ok {
+			last = m.market.price(sp.Id, f)
+		}
+		vals = append(vals, last)
+		rows = append(rows, marketRow{sp: sp, base: base, history: vals})
+	}
+
+	// Biggest discounts first; species trading at base are summarised
+	sort.SliceStable(rows, func(a, b int) bool {
+		ra := float64(rows[a].history[len(rows[a].history)-1]) / float64(rows[a].base)
+		rb := float64(rows[b].history[len(rows[b].history)-1]) / float64(rows[b].base)
+		return ra < rb
+	})
+
+	v := view.MarketView{Global: m.market.global, Days: fish.MarketWindowDays}
+	for _, r := range rows {
+		n := len(r.history)
+		cur, prev := r.history[n-1], r.history[n-2]
+		if speciesId < 0 && cur == r.base && prev == r.base {
+			v.AtBase++
+			continue
+		}
+		v.Rows = append(v.Rows, view.MarketRow{Species: r.sp.Name, Price: cur, Prev: prev, Base: r.base, History: r.history})
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{view.MarketEmbed(v, m.settings.templates(guildId))},
+		},
+	})
+}
@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// profileTTL is how long /profile stats are reused. Profiles get looked up in
+// bursts (people comparing), and a few seconds out of date doesn't matter.
+const profileTTL = 30 * time.Second
+
+type profileKey struct{ guildId, userId int64 }
+
+type cachedStats struct {
+	stats store.UserStats
+	at    time.Time
+}
+
+// profileStats caches store.UserStats per user for profileTTL
+type profileStats struct {
+	store *store.SQLiteStore
+
+	mu    sync.Mutex
+	cache map[profileKey]cachedStats
+}
+
+func newProfileStats(st *store.SQLiteStore) *profileStats {
+	return &profileStats{store: st, cache: make(map[profileKey]cachedStats)}
+}
+
+func (p *profileStats) get(guildId, userId int64) (store.UserStats, error) {
+	key := profileKey{guildId, userId}
+	now := time.Now()
+
+	p.mu.Lock()
+	cs, ok := p.cache[key]
+	p.mu.Unlock()
+	if ok && now.Sub(cs.at) < profileTTL {
+		return cs.stats, nil
+	}
+
+	stats, err := p.store.UserStats(context.TODO(), guildId, userId)
+	if err != nil {
+		return stats, err
+	}
+
+	p.mu.Lock()
+	// Drop expired entries while we're here so the map doesn't grow forever
+	for k, old := range p.cache {
+		if now.Sub(old.at) >= profileTTL {
+			delete(p.cache, k)
+		}
+	}
+	p.cache[key] = cachedStats{stats: stats, at: now}
+	p.mu.Unlock()
+	return stats, nil
+}
+
+func (m *module) handleProfile(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/profile must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	target, member := i.Member.User, i.Member
+	for _, opt := range data.Options {
+		if opt.Name == "user" && data.Resolved != nil {
+			target, member = data.Resolved.Users[opt.Value.(string)], nil
+			if target != nil {
+				member = data.Resolved.Members[target.ID]
+			}
+		}
+	}
+	if target == nil {
+		respondEphemeral(s, i, "Unknown user.")
+		return
+	}
+	if target.Bot {
+		respondEphemeral(s, i, "Bots don't fish.")
+		return
+	}
+	name := displayName(member, target)
+
+	guildId, userId := toInt64(i.GuildID), toInt64(target.ID)
+	stats, err := m.profiles.get(guildId, userId)
+	if err != nil {
+		logREST("failed to load profile stats", err)
+		respondEphemeral(s, i, "Error loading that profile.")
+		return
+	}
+
+	title := ""
+	if a, ok := m.achievements.Get(stats.TitleKey); ok {
+		title = a.Title
+	}
+	v := view.ProfileView{
+		Name:      withTitle(name, title),
+		AvatarURL: target.AvatarURL("128"),
+		Catches:   stats.Catches,
+	}
+	if stats.Catches == 0 {
+		respondProfile(s, i, view.ProfileEmbed(v, m.settings.templates(guildId)))
+		return
+	}
+
+	// Favourite is the most caught species, rarest the lowest weight
+	var (
+		biggest, favourite, rarest fish.Species
+		biggestSize                float64
+		favouriteCount             int
+	)
+	for id, ss := range stats.Species {
+		sp, ok := m.reg.GetById(id)
+		if !ok {
+			continue
+		}
+		if ss.Biggest > biggestSize {
+			biggest, biggestSize = sp, ss.Biggest
+		}
+		if ss.Catches > favouriteCount || (ss.Catches == favouriteCount && sp.Id < favourite.Id) {
+			favourite, favouriteCount = sp, ss.Catches
+		}
+		if rarest.Name == "" || sp.Weight < rarest.Weight || (sp.Weight == rarest.Weight && sp.Id < rarest.Id) {
+			rarest = sp
+		}
+	}
+
+	v.Released = stats.Released
+	v.Species, v.TotalSpecies = len(stats.Species), len(m.reg.All())
+	v.Streak = fish.CurrentStreak(stats.Active, time.Now().In(m.settings.location(guildId)))
+	v.Biggest, v.BiggestSize, v.BiggestClass = biggest.Name, biggestSize, fish.SizeClassFor(biggest, biggestSize).String()
+	v.Rarest, v.RarestTier = rarest.Name, m.picker.SpeciesTier(fish.SpeciesId(rarest.Id)).String()
+	v.Favourite, v.FavouriteCount = favourite.Name, favouriteCount
+	v.Balance = stats.Balance
+	respondProfile(s, i, view.ProfileEmbed(v, m.settings.templates(guildId)))
+}
+
+func respondProfile(s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) {
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{embed},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	}); err != nil {
+		logREST("profile response failed", err)
+	}
+}
@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// questPools are the quest pools in display order with how many quests each
+// user rolls from them
+var questPools = []struct {
+	pool string
+	n    int
+}{
+	{fish.PoolDaily, fish.DailyQuests},
+	{fish.PoolWeekly, fish.WeeklyQuests},
+}
+
+// questSeed makes quest rolls depend only on the user and period
+func questSeed(guildId, userId int64, period string) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%d:%s", guildId, userId, period)
+	return h.Sum64()
+}
+
+// activeQuests returns the user's quests for the guild's current day and
+// week, rolling them on first use
+func (m *module) activeQuests(guildId, userId int64, now time.Time) ([]store.QuestAssignment, error) {
+	now = now.In(m.settings.location(guildId))
+
+	var out []store.QuestAssignment
+	for _, qp := range questPools {
+		period, _ := fish.QuestPeriod(qp.pool, now)
+		rolled := m.quests.Roll(qp.pool, qp.n, questSeed(guildId, userId, period))
+		assign := make([]store.QuestAssignment, len(rolled))
+		for idx, q := range rolled {
+			assign[idx] = store.QuestAssignment{Key: q.Key, Target: q.Target(), Reward: q.Reward}
+		}
+		quests, err := m.store.EnsureQuests(context.TODO(), guildId, userId, period, assign)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, quests...)
+	}
+	return out, nil
+}
+
+// advanceQuests counts catches towards the user's active quests for an event
+// and returns the quests that completed. prices line up with catches and are
+// only needed for sell events.
+func (m *module) advanceQuests(guildId, userId int64, event string, catches []fish.Catch, prices []int64) []store.QuestAssignment {
+	active, err := m.activeQuests(guildId, userId, time.Now())
+	if err != nil {
+		logREST("failed to load quests", err)
+		return nil
+	}
+
+	var progress []store.QuestProgress
+	for _, a := range active {
+		q, ok := m.quests.Get(a.Key)
+		if !ok || !a.CompletedAt.IsZero() {
+			continue
+		}
+		var amount int64
+		for idx, c := range catches {
+			var price int64
+			if idx < len(prices) {
+				price = prices[idx]
+			}
+			amount += q.Advance(m.picker, event, c, price)
+		}
+		if amount > 0 {
+			progress = append(progress, store.QuestProgress{Period: a.Period, Key: a.Key, Amount: amount})
+		}
+	}
+
+	completed, err := m.store.ProgressQuests(context.TODO(), guildId, userId, progress)
+	if err != nil {
+		logREST("failed to progress quests", err)
+		return nil
+	}
+	return completed
+}
+
+// questViews describes completed quests for an embed
+func (m *module) questViews(completed []store.QuestAssignment) []view.QuestView {
+	var out []view.QuestView
+	for _, a := range completed {
+		q, _ := m.quests.Get(a.Key)
+		out = append(out, view.QuestView{Description: q.Description, Reward: a.Reward})
+	}
+	return out
+}
+
+func (m *module) handleQuests(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/quests must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	guildId, userId := toInt64(i.GuildID), toInt64(interactionUserId(i))
+	now := time.Now().In(m.settings.location(guildId))
+	active, err := m.activeQuests(guildId, userId, now)
+	if err != nil {
+		logREST("failed to load quests", err)
+		respondEphemeral(s, i, "Error loading your quests.")
+		return
+	}
+
+	var v view.QuestBoardView
+	for _, qp := range questPools {
+		period, ends := fish.QuestPeriod(qp.pool, now)
+		pv := view.QuestPoolView{Pool: qp.pool, Ends: ends.Unix()}
+		for _, a := range active {
+			if a.Period != period {
+				continue
+			}
+			q, _ := m.quests.Get(a.Key)
+			desc := q.Description
+			if desc == "" {
+				desc = a.Key
+			}
+			pv.Quests = append(pv.Quests, view.QuestProgressView{
+				Description: desc,
+				Progress:    a.Progress,
+				Target:      a.Target,
+				Coins:       q.Coins > 0,
+				Reward:      a.Reward,
+				Done:        !a.CompletedAt.IsZero(),
+			})
+		}
+		v.Pools = append(v.Pools, pv)
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{view.QuestBoardEmbed(v, m.settings.templates(guildId))},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
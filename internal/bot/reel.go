@@ -0,0 +1,206 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// Reeling casts go through three states: waiting for a bite, biting (the Reel
+// button is up), and done. Nothing is stored until the fish is landed, so a
+// cast that is still pending when the bot stops is simply lost.
+const (
+	reelWaiting = iota
+	reelBiting
+	reelDone
+)
+
+type reelSession struct {
+	id          string
+	userId      string
+	cast        cast
+	interaction *discordgo.Interaction
+
+	mu       sync.Mutex
+	state    int
+	deadline time.Time
+	timer    *time.Timer
+}
+
+type reelSessions struct {
+	mu   sync.Mutex
+	open map[string]*reelSession
+}
+
+func newReelSessions() *reelSessions {
+	return &reelSessions{open: make(map[string]*reelSession)}
+}
+
+func (r *reelSessions) add(rs *reelSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.open[rs.id] = rs
+}
+
+func (r *reelSessions) get(id string) (*reelSession, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, ok := r.open[id]
+	return rs, ok
+}
+
+func (r *reelSessions) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.open, id)
+}
+
+// stop cancels every pending bite and timeout
+func (r *reelSessions) stop() {
+	// finish locks r.mu while holding rs.mu, so never hold both the other way
+	r.mu.Lock()
+	open := make([]*reelSession, 0, len(r.open))
+	for _, rs := range r.open {
+		open = append(open, rs)
+	}
+	r.mu.Unlock()
+
+	for _, rs := range open {
+		rs.mu.Lock()
+		if rs.timer != nil {
+			rs.timer.Stop()
+		}
+		rs.mu.Unlock()
+	}
+}
+
+// finish marks the session done and drops it. Callers hold rs.mu.
+func (r *reelSessions) finish(rs *reelSession) {
+	rs.state = reelDone
+	if rs.timer != nil {
+		rs.timer.Stop()
+	}
+	r.remove(rs.id)
+}
+
+// startReel shows the cast line and schedules the bite. The deferred /fish
+// response is the message that gets edited through every step.
+func (m *module) startReel(s *discordgo.Session, i *discordgo.InteractionCreate, c cast) {
+	rs := &reelSession{
+		id:          i.ID,
+		userId:      interactionUserId(i),
+		cast:        c,
+		interaction: i.Interaction,
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{view.ReelCastEmbed(view.ReelView{Angler: c.username}, m.settings.templates(c.catch.GuildId))},
+	}); err != nil {
+		logREST("edit failed", err)
+		return
+	}
+
+	m.reels.add(rs)
+	rs.mu.Lock()
+	rs.timer = time.AfterFunc(fish.BiteDelay(), func() { m.bite(rs) })
+	rs.mu.Unlock()
+}
+
+// bite puts up the Reel button and starts the window to press it
+func (m *module) bite(rs *reelSession) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.state != reelWaiting {
+		return
+	}
+
+	window := fish.ReelWindow(m.picker.SpeciesTier(rs.cast.catch.SpeciesId))
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Reel!", Style: discordgo.SuccessButton, CustomID: "reel|" + rs.id, Emoji: &discordgo.ComponentEmoji{Name: "🎣"}},
+		}},
+	}
+	if _, err := m.s.InteractionResponseEdit(rs.interaction, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{view.ReelBiteEmbed(view.ReelView{Angler: rs.cast.username, Seconds: window.Seconds()}, m.settings.templates(rs.cast.catch.GuildId))},
+		Components: &components,
+	}); err != nil {
+		logREST("edit failed", err)
+		m.reels.finish(rs)
+		return
+	}
+
+	// The window starts once the button is actually on screen
+	rs.state = reelBiting
+	rs.deadline = time.Now().Add(window)
+	rs.timer = time.AfterFunc(window, func() { m.escape(rs) })
+}
+
+// escape is the window running out without a press
+func (m *module) escape(rs *reelSession) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.state != reelBiting {
+		return
+	}
+	m.reels.finish(rs)
+
+	if _, err := m.s.InteractionResponseEdit(rs.interaction, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{m.escapedEmbed(rs.cast)},
+		Components: &[]discordgo.MessageComponent{},
+	}); err != nil {
+		logREST("edit failed", err)
+	}
+}
+
+func (m *module) escapedEmbed(c cast) *discordgo.MessageEmbed {
+	return view.ReelEscapedEmbed(view.ReelView{Angler: c.username}, m.settings.templates(c.catch.GuildId))
+}
+
+// handleReelComponent handles the Reel button ("reel|<session id>")
+func (m *module) handleReelComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_, id, _ := strings.Cut(i.MessageComponentData().CustomID, "|")
+	rs, ok := m.reels.get(id)
+	if !ok {
+		respondEphemeral(s, i, "This line has already been reeled in.")
+		return
+	}
+	if interactionUserId(i) != rs.userId {
+		respondEphemeral(s, i, "That's not your line!")
+		return
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.state != reelBiting {
+		respondEphemeral(s, i, "This line has already been reeled in.")
+		return
+	}
+	m.reels.finish(rs)
+
+	data := &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{m.escapedEmbed(rs.cast)},
+		Components: []discordgo.MessageComponent{},
+	}
+	if !time.Now().After(rs.deadline) {
+		// Hooking a huge fish starts a fight instead of landing it
+		if fs := m.startFight(rs.interaction, rs.cast); fs != nil {
+			data.Embeds = []*discordgo.MessageEmbed{m.fightEmbed(fs, fish.FightReel)}
+			data.Components = fightComponents(fs)
+		} else {
+			l := m.landCatch(rs.cast)
+			data.Embeds = []*discordgo.MessageEmbed{l.embed}
+			data.Components = l.components
+			data.Files = l.files
+		}
+	}
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: data,
+	}); err != nil {
+		logREST("reel update failed", err)
+	}
+}
@@ -0,0 +1,178 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// releaseComponents is the Release button under a freshly landed catch
+func releaseComponents(catchId, points int64) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    fmt.Sprintf("Release (+%d)", points),
+				Style:    discordgo.SecondaryButton,
+				CustomID: fmt.Sprintf("release|%d", catchId),
+				Emoji:    &discordgo.ComponentEmoji{Name: "🌿"},
+			},
+		}},
+	}
+}
+
+// handleReleaseComponent handles the Release button ("release|<catch id>").
+// The catch stays in the fishbook and on the leaderboard but leaves the
+// angler's inventory.
+func (m *module) handleReleaseComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_, idStr, _ := strings.Cut(i.MessageComponentData().CustomID, "|")
+	catchId, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondEphemeral(s, i, "Unknown catch.")
+		return
+	}
+	guildId, userId := toInt64(i.GuildID), toInt64(interactionUserId(i))
+
+	// Points depend on the fish, so find it among what the user still has
+	owned, err := m.store.UnsoldCatches(context.TODO(), guildId, userId, -1)
+	if err != nil {
+		logREST("failed to load catches", err)
+		respondEphemeral(s, i, "Error releasing that fish.")
+		return
+	}
+	var c fish.Catch
+	for _, o := range owned {
+		if o.Id == catchId {
+			c = o
+			break
+		}
+	}
+	if c.Id == 0 {
+		respondEphemeral(s, i, "You can only release a fish you caught and still have.")
+		return
+	}
+
+	sp, _ := m.reg.GetById(c.SpeciesId)
+	points := fish.ReleasePoints(m.picker.SpeciesTier(c.SpeciesId), fish.SizeClassFor(sp, c.Size))
+	if err := m.store.ReleaseCatch(context.TODO(), guildId, userId, catchId, points); err != nil {
+		if errors.Is(err, store.ErrCatchNotReleased) {
+			respondEphemeral(s, i, "You can only release a fish you caught and still have.")
+			return
+		}
+		logREST("failed to release catch", err)
+		respondEphemeral(s, i, "Error releasing that fish.")
+		return
+	}
+
+	total, err := m.store.ConservationPoints(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load conservation points", err)
+	}
+
+	embeds := i.Message.Embeds
+	if len(embeds) > 0 {
+		v := view.ReleasedView{Points: points, Total: total}
+		embeds[0].Fields = append(embeds[0].Fields, view.ReleasedField(v, m.settings.templates(guildId)))
+	}
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     embeds,
+			Components: []discordgo.MessageComponent{},
+		},
+	}); err != nil {
+		logREST("release update failed", err)
+	}
+}
+
+func (m *module) handleConservation(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/conservation must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	guildId, userId := toInt64(i.GuildID), toInt64(interactionUserId(i))
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name != "redeem" {
+			continue
+		}
+		key := opt.StringValue()
+		a, ok := m.achievements.Get(key)
+		if !ok || a.Cost == 0 {
+			respondEphemeral(s, i, fmt.Sprintf("Unknown title '%s' - see `/conservation`.", key))
+			return
+		}
+		err := m.store.RedeemTitle(context.TODO(), guildId, userId, a.Key, a.Cost)
+		switch {
+		case errors.Is(err, store.ErrAlreadyRedeemed):
+			respondEphemeral(s, i, fmt.Sprintf("You already have **%s** - equip it with `/titles`.", a.Title))
+			return
+		case errors.Is(err, store.ErrNotEnoughPoints):
+			respondEphemeral(s, i, fmt.Sprintf("**%s** costs %d 🌿 - release more fish to earn points.", a.Title, a.Cost))
+			return
+		case err != nil:
+			logREST("failed to redeem title", err)
+			respondEphemeral(s, i, "Error redeeming that title.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("🌿 Redeemed **%s**! Equip it with `/titles title:`.", a.Title))
+		return
+	}
+
+	// No title given: show the balance and what it can buy
+	points, err := m.store.ConservationPoints(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load conservation points", err)
+		respondEphemeral(s, i, "Error loading your conservation points.")
+		return
+	}
+	earned, err := m.store.Achievements(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load achievements", err)
+	}
+
+	v := view.ConservationView{Points: points}
+	for _, a := range m.achievements.All() {
+		if a.Cost == 0 {
+			continue
+		}
+		_, redeemed := earned[a.Key]
+		v.Titles = append(v.Titles, view.ConservationTitle{Title: a.Title, Cost: a.Cost, Redeemed: redeemed})
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{view.ConservationEmbed(v, m.settings.templates(guildId))},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func (m *module) handleConservationAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var typed string
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "redeem" && opt.Focused {
+			typed = opt.StringValue()
+		}
+	}
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, a := range m.achievements.All() {
+		if a.Cost == 0 || !matchesTyped(typed, a.Key, a.Title) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s (%d points)", a.Title, a.Cost),
+			Value: a.Key,
+		})
+	}
+	respondAutocomplete(s, i, limitChoices(choices))
+}
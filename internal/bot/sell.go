@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// priceOf is what the market pays for a catch given the current market
+// factors (see marketPrices.factors). Missing factors mean base price.
+func (m *module) priceOf(c fish.Catch, factors map[fish.SpeciesId]float64) int64 {
+	sp, ok := m.reg.GetById(c.SpeciesId)
+	if !ok {
+		return 0
+	}
+	factor, ok := factors[c.SpeciesId]
+	if !ok {
+		factor = 1
+	}
+	return fish.MarketPrice(m.picker.SpeciesTier(c.SpeciesId), fish.SizePercentile(sp, c.Size), factor)
+}
+
+func (m *module) handleSell(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/sell must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		respondEphemeral(s, i, "Choose what to sell.")
+		return
+	}
+	sub := data.Options[0]
+
+	guildId := toInt64(i.GuildID)
+	userId := toInt64(interactionUserId(i))
+
+	var (
+		speciesId = fish.SpeciesId(-1)
+		catchId   int64
+		below     = fish.SizeClass(-1)
+	)
+	for _, opt := range sub.Options {
+		switch opt.Name {
+		case "id":
+			catchId = opt.IntValue()
+		case "species":
+			fishKey := opt.StringValue()
+			var ok bool
+			speciesId, ok = m.reg.IdByKey(fishKey)
+			if !ok {
+				respondEphemeral(s, i, fmt.Sprintf("Unknown fish '%s'", fishKey))
+				return
+			}
+		case "size":
+			n, _ := strconv.Atoi(opt.StringValue())
+			below = fish.SizeClass(n)
+		}
+	}
+
+	unsold, err := m.store.UnsoldCatches(context.TODO(), guildId, userId, speciesId)
+	if err != nil {
+		logREST("failed to load catches", err)
+		respondEphemeral(s, i, "Error loading your catches.")
+		return
+	}
+
+	// Record the day's opening prices before this sale moves them
+	m.market.snapshot(guildId)
+	factors := m.market.factors(guildId)
+	var sales []store.Sale
+	byId := make(map[int64]fish.Catch, len(unsold))
+	for _, c := range unsold {
+		byId[c.Id] = c
+		switch sub.Name {
+		case "catch":
+			if c.Id != catchId {
+				continue
+			}
+		case "below":
+			sp, _ := m.reg.GetById(c.SpeciesId)
+			if fish.SizeClassFor(sp, c.Size) >= below {
+				continue
+			}
+		}
+		sales = append(sales, store.Sale{CatchId: c.Id, SpeciesId: c.SpeciesId, Price: m.priceOf(c, factors)})
+	}
+
+	if len(sales) == 0 {
+		if sub.Name == "catch" {
+			respondEphemeral(s, i, fmt.Sprintf("You don't have an unsold catch #%d.", catchId))
+		} else {
+			respondEphemeral(s, i, "You don't have any matching fish to sell.")
+		}
+		return
+	}
+
+	sold, total, err := m.store.SellCatches(context.TODO(), guildId, userId, sales)
+	if err != nil {
+		logREST("failed to sell", err)
+		respondEphemeral(s, i, "The market is closed right now, try again later.")
+		return
+	}
+	if len(sold) == 0 {
+		respondEphemeral(s, i, "Those fish have already been sold.")
+		return
+	}
+	m.market.invalidate(guildId)
+
+	soldCatches := make([]fish.Catch, len(sold))
+	prices := make([]int64, len(sold))
+	for idx, sale := range sold {
+		soldCatches[idx], prices[idx] = byId[sale.CatchId], sale.Price
+	}
+	completed := m.advanceQuests(guildId, userId, fish.QuestSell, soldCatches, prices)
+
+	balance, err := m.store.Balance(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load balance", err)
+	}
+
+	v := view.SellView{Count: len(sold), Total: total, Balance: balance, Quests: m.questViews(completed)}
+	if len(sold) == 1 && sub.Name == "catch" {
+		v.CatchId = catchId
+	}
+	embed := view.SellEmbed(v, m.settings.templates(guildId))
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
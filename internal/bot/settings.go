@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// guildSettings caches store.GuildSettings per guild. Each guild is only ever
+// handled by one shard, so invalidating on write is enough to stay fresh.
+type guildSettings struct {
+	store *store.SQLiteStore
+
+	mu    sync.Mutex
+	cache map[int64]store.GuildSettings
+}
+
+func newGuildSettings(st *store.SQLiteStore) *guildSettings {
+	return &guildSettings{store: st, cache: make(map[int64]store.GuildSettings)}
+}
+
+func (g *guildSettings) get(guildId int64) store.GuildSettings {
+	g.mu.Lock()
+	gs, ok := g.cache[guildId]
+	g.mu.Unlock()
+	if ok {
+		return gs
+	}
+
+	gs, err := g.store.GuildSettings(context.TODO(), guildId)
+	if err != nil {
+		// Don't cache failures; defaults apply until the next lookup succeeds
+		log.Printf("failed to load guild settings for %d: %v", guildId, err)
+		return store.GuildSettings{GuildId: guildId}
+	}
+
+	g.mu.Lock()
+	g.cache[guildId] = gs
+	g.mu.Unlock()
+	return gs
+}
+
+func (g *guildSettings) invalidate(guildId int64) {
+	g.mu.Lock()
+	delete(g.cache, guildId)
+	g.mu.Unlock()
+}
+
+// location is the guild's configured timezone, UTC by default
+func (g *guildSettings) location(guildId int64) *time.Location {
+	tz := g.get(guildId).Timezone
+	if tz == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		log.Printf("guild %d has a bad timezone %q: %v", guildId, tz, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// templates are the guild's message template overrides
+func (g *guildSettings) templates(guildId int64) view.Overrides {
+	return view.Overrides(g.get(guildId).Templates)
+}
+
+// fishingCooldown satisfies ratelimit.ResolverFunc for the fishing limiter
+func (g *guildSettings) fishingCooldown(guildId string) (time.Duration, time.Duration, bool) {
+	gs := g.get(toInt64(guildId))
+	if gs.FishingCooldownMin <= 0 && gs.FishingCooldownMax <= 0 {
+		return 0, 0, false
+	}
+	return gs.FishingCooldownMin, gs.FishingCooldownMax, true
+}
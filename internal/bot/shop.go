@@ -0,0 +1,253 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+var gearSlots = []fish.GearSlot{fish.SlotRod, fish.SlotReel, fish.SlotLine}
+
+// loadoutModifiers sums the user's equipped gear. Errors fall back to no
+// modifiers so a broken loadout never blocks fishing.
+func (m *module) loadoutModifiers(guildId, userId int64) fish.Modifiers {
+	loadout, err := m.store.Loadout(context.TODO(), guildId, userId)
+	if err != nil {
+		log.Printf("failed to load loadout: %v", err)
+		return fish.Modifiers{}
+	}
+
+	keys := make([]string, 0, len(loadout))
+	for _, k := range loadout {
+		keys = append(keys, k)
+	}
+	return m.gear.Modifiers(keys...)
+}
+
+func (m *module) handleShop(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/shop must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	guildId := toInt64(i.GuildID)
+	userId := toInt64(interactionUserId(i))
+
+	owned, err := m.store.OwnedGear(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load gear", err)
+		respondEphemeral(s, i, "Error loading the shop.")
+		return
+	}
+	loadout, err := m.store.Loadout(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load loadout", err)
+		respondEphemeral(s, i, "Error loading the shop.")
+		return
+	}
+	balance, err := m.store.Balance(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load balance", err)
+	}
+
+	v := view.ShopView{Balance: balance}
+	all := m.gear.All()
+	for _, slot := range gearSlots {
+		sv := view.ShopSlot{Slot: string(slot)}
+		for _, g := range all {
+			if g.Slot != slot {
+				continue
+			}
+			sv.Items = append(sv.Items, view.ShopGear{
+				Name:        g.Name,
+				Key:         g.Key,
+				Description: g.Description,
+				Price:       g.Price,
+				Equipped:    loadout[string(slot)] == g.Key,
+				Owned:       owned[g.Key],
+			})
+		}
+		v.Slots = append(v.Slots, sv)
+	}
+
+	baitCounts, err := m.store.BaitCounts(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load bait", err)
+	}
+	for _, bt := range m.bait.All() {
+		v.Bait = append(v.Bait, view.ShopBait{
+			Name:        bt.Name,
+			Key:         bt.Key,
+			Description: bt.Description,
+			Price:       bt.Price,
+			Pack:        bt.Pack,
+			Have:        baitCounts[bt.Key],
+		})
+	}
+	embed := view.ShopEmbed(v, m.settings.templates(guildId))
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func (m *module) handleBuy(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/buy must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	var key string
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "item" {
+			key = opt.StringValue()
+		}
+	}
+
+	guildId := toInt64(i.GuildID)
+	userId := toInt64(interactionUserId(i))
+
+	if bt, ok := m.bait.Get(key); ok {
+		m.buyBait(s, i, guildId, userId, bt)
+		return
+	}
+
+	g, ok := m.gear.Get(key)
+	if !ok {
+		respondEphemeral(s, i, fmt.Sprintf("Unknown item '%s' - see `/shop`.", key))
+		return
+	}
+
+	err := m.store.BuyGear(context.TODO(), guildId, userId, g.Key, string(g.Slot), g.Price)
+	switch {
+	case errors.Is(err, store.ErrAlreadyOwned):
+		// Buying something you own just re-equips it
+		if err := m.store.EquipGear(context.TODO(), guildId, userId, g.Key, string(g.Slot)); err != nil {
+			logREST("failed to equip", err)
+			respondEphemeral(s, i, "Failed to equip that item.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("🎣 Equipped your **%s**.", g.Name))
+	case errors.Is(err, store.ErrInsufficientFunds):
+		respondEphemeral(s, i, fmt.Sprintf("You can't afford the **%s** (%d 🪙). Sell some fish first!", g.Name, g.Price))
+	case err != nil:
+		logREST("failed to buy", err)
+		respondEphemeral(s, i, "The shop is closed right now, try again later.")
+	default:
+		respondEphemeral(s, i, fmt.Sprintf("🛒 Bought and equipped the **%s** for %d 🪙.", g.Name, g.Price))
+	}
+}
+
+func (m *module) buyBait(s *discordgo.Session, i *discordgo.InteractionCreate, guildId, userId int64, bt fish.Bait) {
+	err := m.store.BuyBait(context.TODO(), guildId, userId, bt.Key, bt.Pack, bt.Price)
+	switch {
+	case errors.Is(err, store.ErrInsufficientFunds):
+		respondEphemeral(s, i, fmt.Sprintf("You can't afford **%s** (%d 🪙). Sell some fish first!", bt.Name, bt.Price))
+	case err != nil:
+		logREST("failed to buy bait", err)
+		respondEphemeral(s, i, "The shop is closed right now, try again later.")
+	default:
+		respondEphemeral(s, i, fmt.Sprintf("🪱 Bought %dx **%s** for %d 🪙. Use it with `/fish bait:`.", bt.Pack, bt.Name, bt.Price))
+	}
+}
+
+// autocompleteShopItems suggests catalog items matching what's been typed
+func (m *module) autocompleteShopItems(typed string) []*discordgo.ApplicationCommandOptionChoice {
+	var out []*discordgo.ApplicationCommandOptionChoice
+	for _, g := range m.gear.All() {
+		if !matchesTyped(typed, g.Key, g.Name) {
+			continue
+		}
+		out = append(out, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s (%d 🪙)", g.Name, g.Price),
+			Value: g.Key,
+		})
+	}
+	for _, bt := range m.bait.All() {
+		if !matchesTyped(typed, bt.Key, bt.Name) {
+			continue
+		}
+		out = append(out, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s x%d (%d 🪙)", bt.Name, bt.Pack, bt.Price),
+			Value: bt.Key,
+		})
+	}
+	return limitChoices(out)
+}
+
+// autocompleteOwnedBait only suggests bait the user actually has
+func (m *module) autocompleteOwnedBait(guildId, userId int64, typed string) []*discordgo.ApplicationCommandOptionChoice {
+	counts, err := m.store.BaitCounts(context.TODO(), guildId, userId)
+	if err != nil {
+		logREST("failed to load bait", err)
+		return nil
+	}
+
+	var out []*discordgo.ApplicationCommandOptionChoice
+	for _, bt := range m.bait.All() {
+		n := counts[bt.Key]
+		if n == 0 || !matchesTyped(typed, bt.Key, bt.Name) {
+			continue
+		}
+		out = append(out, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s (%d left)", bt.Name, n),
+			Value: bt.Key,
+		})
+	}
+	return limitChoices(out)
+}
+
+func (m *module) handleFishAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var typed string
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "bait" && opt.Focused {
+			typed = opt.StringValue()
+		}
+	}
+	respondAutocomplete(s, i, m.autocompleteOwnedBait(toInt64(i.GuildID), toInt64(interactionUserId(i)), typed))
+}
+
+func matchesTyped(typed, key, name string) bool {
+	typed = strings.ToLower(typed)
+	return typed == "" || strings.Contains(strings.ToLower(name), typed) || strings.Contains(key, typed)
+}
+
+// Discord accepts at most 25 autocomplete choices
+func limitChoices(choices []*discordgo.ApplicationCommandOptionChoice) []*discordgo.ApplicationCommandOptionChoice {
+	if len(choices) > 25 {
+		return choices[:25]
+	}
+	return choices
+}
+
+func (m *module) handleBuyAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var typed string
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "item" && opt.Focused {
+			typed = opt.StringValue()
+		}
+	}
+	respondAutocomplete(s, i, m.autocompleteShopItems(typed))
+}
+
+func respondAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate, choices []*discordgo.ApplicationCommandOptionChoice) {
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	}); err != nil {
+		logREST("autocomplete failed", err)
+	}
+}
@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// Team names are shown in embeds, so keep them to plain words
+var teamNamePattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} '_-]{1,31}$`)
+
+// teamPeriods are the windows /leaderboard scope:team can aggregate over
+var teamPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+}
+
+func (m *module) handleTeam(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/team must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		respondEphemeral(s, i, "Choose a team action.")
+		return
+	}
+	sub := data.Options[0]
+
+	var name string
+	for _, opt := range sub.Options {
+		if opt.Name == "name" {
+			name = strings.Join(strings.Fields(opt.StringValue()), " ")
+		}
+	}
+
+	guildId, userId := toInt64(i.GuildID), toInt64(interactionUserId(i))
+	now := time.Now()
+
+	switch sub.Name {
+	case "create":
+		if !teamNamePattern.MatchString(name) {
+			respondEphemeral(s, i, "Team names are 2-32 letters, numbers, spaces, dashes or underscores.")
+			return
+		}
+		t, err := m.store.CreateTeam(context.TODO(), guildId, userId, name, now)
+		if errors.Is(err, store.ErrTeamExists) {
+			respondEphemeral(s, i, fmt.Sprintf("There's already a team called **%s** - join it with `/team join`.", name))
+			return
+		}
+		if err != nil {
+			logREST("failed to create team", err)
+			respondEphemeral(s, i, "Error creating the team.")
+			return
+		}
+		respondTeam(s, i, fmt.Sprintf("<@%d> founded team **%s**! Join with `/team join name:%s`.", userId, t.Name, t.Name))
+
+	case "join":
+		t, err := m.store.JoinTeam(context.TODO(), guildId, userId, name, now)
+		switch {
+		case errors.Is(err, store.ErrNoTeam):
+			respondEphemeral(s, i, fmt.Sprintf("There's no team called **%s**.", name))
+			return
+		case errors.Is(err, store.ErrInTeam):
+			respondEphemeral(s, i, fmt.Sprintf("You're already on **%s**.", t.Name))
+			return
+		case err != nil:
+			logREST("failed to join team", err)
+			respondEphemeral(s, i, "Error joining the team.")
+			return
+		}
+		respondTeam(s, i, fmt.Sprintf("<@%d> joined team **%s** (%d members).", userId, t.Name, t.Members))
+
+	case "leave":
+		t, err := m.store.LeaveTeam(context.TODO(), guildId, userId, now)
+		if errors.Is(err, store.ErrNotInTeam) {
+			respondEphemeral(s, i, "You're not on a team.")
+			return
+		}
+		if err != nil {
+			logREST("failed to leave team", err)
+			respondEphemeral(s, i, "Error leaving the team.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("You left **%s**. Catches you made while on the team still count for it.", t.Name))
+
+	default:
+		respondEphemeral(s, i, "Unknown team action.")
+	}
+}
+
+// respondTeam announces a team change publicly without pinging anyone
+func respondTeam(s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         msg,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func (m *module) handleTeamAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var typed string
+	data := i.ApplicationCommandData()
+	if len(data.Options) > 0 {
+		for _, opt := range data.Options[0].Options {
+			if opt.Name == "name" && opt.Focused {
+				typed = opt.StringValue()
+			}
+		}
+	}
+
+	teams, err := m.store.Teams(context.TODO(), toInt64(i.GuildID))
+	if err != nil {
+		logREST("failed to load teams", err)
+	}
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, t := range teams {
+		if !matchesTyped(typed, strings.ToLower(t.Name), t.Name) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s (%d members)", t.Name, t.Members),
+			Value: t.Name,
+		})
+	}
+	respondAutocomplete(s, i, limitChoices(choices))
+}
+
+// teamLeaderboard renders /leaderboard scope:team. The response has already
+// been deferred.
+func (m *module) teamLeaderboard(s *discordgo.Session, i *discordgo.InteractionCreate, period string) {
+	since := time.Time{}
+	v := view.TeamBoardView{}
+	if d, ok := teamPeriods[period]; ok {
+		since = time.Now().Add(-d)
+		v.Period = period
+	}
+
+	stats, err := m.store.TeamStats(context.TODO(), toInt64(i.GuildID), since, 10)
+	if err != nil {
+		editResponseText(s, i, "Error loading leaderboard.")
+		logREST("failed to load team stats", err)
+		return
+	}
+	if len(stats) == 0 {
+		editResponseText(s, i, "No team catches yet - start one with `/team create`!")
+		return
+	}
+
+	for idx, ts := range stats {
+		sp, _ := m.reg.GetById(ts.Best.SpeciesId)
+		v.Rows = append(v.Rows, view.TeamRow{
+			Pos:         idx + 1,
+			Name:        ts.Name,
+			Catches:     ts.Catches,
+			Species:     ts.Species,
+			Members:     ts.Members,
+			BestSize:    ts.Best.Size,
+			BestSpecies: sp.Name,
+			BestClass:   fish.SizeClassFor(sp, ts.Best.Size).String(),
+			BestUserId:  ts.Best.UserId,
+		})
+	}
+
+	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{view.TeamBoardEmbed(v, m.settings.templates(toInt64(i.GuildID)))},
+	})
+}
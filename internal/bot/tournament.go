@@ -0,0 +1,512 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+const (
+	tournamentTick       = time.Minute
+	tournamentPlaces     = 10 // standings shown and results recorded
+	tournamentMaxMinutes = 7 * 24 * 60
+)
+
+func (m *module) handleTournament(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/tournament must be run in a server)
+	if i.GuildID == "" || i.Member == nil {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		respondEphemeral(s, i, "Choose a tournament action.")
+		return
+	}
+	sub := data.Options[0]
+
+	// Anyone can join or check a tournament, but running them is for admins
+	if (sub.Name == "create" || sub.Name == "end") && i.Member.Permissions&discordgo.PermissionManageGuild == 0 {
+		respondEphemeral(s, i, "You need the **Manage Server** permission to run tournaments.")
+		return
+	}
+
+	if sub.Name == "create" {
+		m.createTournament(s, i, sub.Options)
+		return
+	}
+
+	var tournamentId int64
+	for _, opt := range sub.Options {
+		if opt.Name == "tournament" {
+			tournamentId = opt.IntValue()
+		}
+	}
+	t, ok := m.pickTournament(s, i, tournamentId)
+	if !ok {
+		return
+	}
+
+	switch sub.Name {
+	case "join":
+		m.joinTournament(s, i, t)
+	case "status":
+		m.tournamentStatus(s, i, t)
+	case "end":
+		m.endTournament(s, i, t)
+	default:
+		respondEphemeral(s, i, "Unknown tournament action.")
+	}
+}
+
+// pickTournament loads the tournament the user asked for, or the guild's only
+// open one if they didn't say. Responds with an error and returns false if
+// there isn't exactly one to pick.
+func (m *module) pickTournament(s *discordgo.Session, i *discordgo.InteractionCreate, tournamentId int64) (store.Tournament, bool) {
+	guildId := toInt64(i.GuildID)
+	if tournamentId != 0 {
+		t, err := m.store.Tournament(context.TODO(), tournamentId)
+		if errors.Is(err, store.ErrNoTournament) || (err == nil && t.GuildId != guildId) {
+			respondEphemeral(s, i, fmt.Sprintf("There's no tournament #%d.", tournamentId))
+			return t, false
+		}
+		if err != nil {
+			logREST("failed to load tournament", err)
+			respondEphemeral(s, i, "Error loading the tournament.")
+			return t, false
+		}
+		return t, true
+	}
+
+	open, err := m.store.OpenTournaments(context.TODO(), guildId)
+	if err != nil {
+		logREST("failed to load tournaments", err)
+		respondEphemeral(s, i, "Error loading tournaments.")
+		return store.Tournament{}, false
+	}
+	switch len(open) {
+	case 0:
+		respondEphemeral(s, i, "There are no tournaments running right now.")
+		return store.Tournament{}, false
+	case 1:
+		return open[0], true
+	}
+	names := make([]string, len(open))
+	for idx, t := range open {
+		names[idx] = fmt.Sprintf("#%d %s", t.Id, t.Name)
+	}
+	respondEphemeral(s, i, "Several tournaments are running, pick one with `tournament:` - "+strings.Join(names, ", "))
+	return store.Tournament{}, false
+}
+
+// parseStartTime reads an "HH:MM" time of day in now's location, taking the
+// next time it comes around. Empty means now.
+func parseStartTime(clock string, now time.Time) (time.Time, bool) {
+	if clock == "" {
+		return now, true
+	}
+	tod, err := time.Parse("15:04", clock)
+	if err != nil {
+		return now, false
+	}
+	y, mo, d := now.Date()
+	start := time.Date(y, mo, d, tod.Hour(), tod.Minute(), 0, 0, now.Location())
+	if start.Before(now) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start, true
+}
+
+func (m *module) createTournament(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	guildId := toInt64(i.GuildID)
+	loc := m.settings.location(guildId)
+	now := time.Now().In(loc)
+
+	t := store.Tournament{
+		GuildId:   guildId,
+		ChannelId: toInt64(i.ChannelID),
+		CreatorId: toInt64(interactionUserId(i)),
+		Target:    fish.TournamentTarget{Species: -1},
+		Metric:    fish.MetricLargest,
+	}
+	var (
+		minutes int64
+		start   string
+	)
+	for _, opt := range opts {
+		switch opt.Name {
+		case "name":
+			t.Name = strings.TrimSpace(opt.StringValue())
+		case "minutes":
+			minutes = opt.IntValue()
+		case "metric":
+			t.Metric = opt.StringValue()
+		case "species":
+			key := opt.StringValue()
+			id, ok := m.reg.IdByKey(key)
+			if !ok {
+				respondEphemeral(s, i, fmt.Sprintf("Unknown fish '%s'", key))
+				return
+			}
+			t.Target.Species = id
+		case "tag":
+			t.Target.Tag = strings.ToLower(strings.TrimSpace(opt.StringValue()))
+		case "start":
+			start = strings.TrimSpace(opt.StringValue())
+		case "prize":
+			t.Prize = opt.IntValue()
+		}
+	}
+
+	if t.Target.Tag != "" && !m.knownTag(t.Target.Tag) {
+		respondEphemeral(s, i, fmt.Sprintf("No fish are tagged '%s'.", t.Target.Tag))
+		return
+	}
+	if t.Target.Species >= 0 && t.Target.Tag != "" {
+		respondEphemeral(s, i, "Pick a species or a tag, not both.")
+		return
+	}
+	if minutes <= 0 || minutes > tournamentMaxMinutes {
+		respondEphemeral(s, i, "Tournaments can run for up to a week.")
+		return
+	}
+	var ok bool
+	if t.StartsAt, ok = parseStartTime(start, now); !ok {
+		respondEphemeral(s, i, fmt.Sprintf("Give the start as a time of day like `20:00` (%s).", loc.String()))
+		return
+	}
+	t.EndsAt = t.StartsAt.Add(time.Duration(minutes) * time.Minute)
+
+	id, err := m.store.CreateTournament(context.TODO(), t)
+	if err != nil {
+		logREST("failed to create tournament", err)
+		respondEphemeral(s, i, "Error creating the tournament.")
+		return
+	}
+	t.Id = id
+
+	// The create response doubles as the live standings message
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{m.tournamentEmbed(t, nil, 0, now)},
+		},
+	}); err != nil {
+		logREST("respond failed", err)
+		return
+	}
+	msg, err := s.InteractionResponse(i.Interaction)
+	if err != nil {
+		logREST("failed to fetch tournament message", err)
+		return
+	}
+	if err := m.store.SetStandingsMessage(context.TODO(), id, toInt64(msg.ID)); err != nil {
+		log.Printf("failed to save standings message for tournament %d: %v", id, err)
+	}
+}
+
+// knownTag reports whether any species has the tag
+func (m *module) knownTag(tag string) bool {
+	for _, sp := range m.reg.All() {
+		if sp.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *module) joinTournament(s *discordgo.Session, i *discordgo.InteractionCreate, t store.Tournament) {
+	now := time.Now()
+	err := m.store.JoinTournament(context.TODO(), t.Id, toInt64(interactionUserId(i)), now)
+	switch {
+	case errors.Is(err, store.ErrAlreadyJoined):
+		respondEphemeral(s, i, fmt.Sprintf("You're already in **%s**.", t.Name))
+		return
+	case errors.Is(err, store.ErrTournamentClosed):
+		respondEphemeral(s, i, fmt.Sprintf("**%s** is already over.", t.Name))
+		return
+	case err != nil:
+		logREST("failed to join tournament", err)
+		respondEphemeral(s, i, "Error joining the tournament.")
+		return
+	}
+
+	when := fmt.Sprintf("ends <t:%d:R>", t.EndsAt.Unix())
+	if now.Before(t.StartsAt) {
+		when = fmt.Sprintf("starts <t:%d:R>", t.StartsAt.Unix())
+	}
+	respondEphemeral(s, i, fmt.Sprintf("You're in **%s** - it %s. Catches of %s from now on count.",
+		t.Name, when, t.Target.Describe(m.reg)))
+}
+
+func (m *module) tournamentStatus(s *discordgo.Session, i *discordgo.InteractionCreate, t store.Tournament) {
+	var embed *discordgo.MessageEmbed
+	if t.Status == store.TournamentOpen {
+		standings, entrants, err := m.standings(t)
+		if err != nil {
+			logREST("failed to load standings", err)
+			respondEphemeral(s, i, "Error loading the standings.")
+			return
+		}
+		embed = m.tournamentEmbed(t, standings, entrants, time.Now())
+	} else {
+		results, err := m.store.TournamentResults(context.TODO(), t.Id)
+		if err != nil {
+			logREST("failed to load results", err)
+			respondEphemeral(s, i, "Error loading the results.")
+			return
+		}
+		embed = m.tournamentResultsEmbed(t, results)
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
+
+func (m *module) endTournament(s *discordgo.Session, i *discordgo.InteractionCreate, t store.Tournament) {
+	if t.Status != store.TournamentOpen {
+		respondEphemeral(s, i, fmt.Sprintf("**%s** is already over.", t.Name))
+		return
+	}
+
+	// Ending before the start calls it off rather than crowning nobody
+	now := time.Now()
+	if now.Before(t.StartsAt) {
+		err := m.store.EndTournament(context.TODO(), t.Id, nil, true, now)
+		if err != nil && !errors.Is(err, store.ErrTournamentClosed) {
+			logREST("failed to cancel tournament", err)
+			respondEphemeral(s, i, "Error cancelling the tournament.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("**%s** was cancelled before it started.", t.Name))
+		return
+	}
+
+	if err := m.finishTournament(t, now); err != nil {
+		logREST("failed to end tournament", err)
+		respondEphemeral(s, i, "Error ending the tournament.")
+		return
+	}
+	// The scheduler posts the results so they're only ever announced once
+	respondEphemeral(s, i, fmt.Sprintf("**%s** is over - results will be posted shortly.", t.Name))
+}
+
+// standings ranks an open tournament's entrants as of now
+func (m *module) standings(t store.Tournament) ([]fish.Standing, int, error) {
+	catches, err := m.store.TournamentCatches(context.TODO(), t)
+	if err != nil {
+		return nil, 0, err
+	}
+	entrants, err := m.store.TournamentEntrants(context.TODO(), t.Id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return fish.RankTournament(m.reg, t.Target, t.Metric, catches), entrants, nil
+}
+
+// tournamentView describes a tournament, without any standings
+func (m *module) tournamentView(t store.Tournament) view.TournamentView {
+	return view.TournamentView{
+		Id:       t.Id,
+		Name:     t.Name,
+		Metric:   t.Metric,
+		Target:   t.Target.Describe(m.reg),
+		StartsAt: t.StartsAt.Unix(),
+		EndsAt:   t.EndsAt.Unix(),
+		Prize:    t.Prize,
+	}
+}
+
+func (m *module) tournamentEmbed(t store.Tournament, standings []fish.Standing, entrants int, now time.Time) *discordgo.MessageEmbed {
+	v := m.tournamentView(t)
+	v.Upcoming = now.Before(t.StartsAt)
+	v.Entrants = entrants
+	for idx, st := range standings {
+		if idx == tournamentPlaces {
+			break
+		}
+		sp, _ := m.reg.GetById(st.Best.SpeciesId)
+		v.Rows = append(v.Rows, view.StandingRow{
+			Place:   idx + 1,
+			UserId:  st.UserId,
+			Score:   fish.FormatScore(t.Metric, st.Score),
+			Size:    st.Best.Size,
+			Species: sp.Name,
+		})
+	}
+	return view.TournamentEmbed(v, m.settings.templates(t.GuildId))
+}
+
+func (m *module) tournamentResultsEmbed(t store.Tournament, results []store.TournamentResult) *discordgo.MessageEmbed {
+	v := m.tournamentView(t)
+	for _, r := range results {
+		v.Rows = append(v.Rows, view.StandingRow{
+			Place:  r.Rank,
+			UserId: r.UserId,
+			Score:  fish.FormatScore(t.Metric, r.Score),
+			Prize:  r.Prize,
+		})
+	}
+	return view.TournamentResultsEmbed(v, m.settings.templates(t.GuildId))
+}
+
+// finishTournament scores the tournament up to now, records the top places
+// with their prizes and closes it. It's fine for two callers to race; only
+// one gets to end it.
+func (m *module) finishTournament(t store.Tournament, now time.Time) error {
+	if now.Before(t.EndsAt) {
+		t.EndsAt = now
+	}
+	standings, _, err := m.standings(t)
+	if err != nil {
+		return err
+	}
+	standings = standings[:min(len(standings), tournamentPlaces)]
+	prizes := fish.TournamentPrizes(t.Prize, len(standings))
+
+	results := make([]store.TournamentResult, len(standings))
+	for idx, st := range standings {
+		results[idx] = store.TournamentResult{Rank: idx + 1, UserId: st.UserId, Score: st.Score}
+		if idx < len(prizes) {
+			results[idx].Prize = prizes[idx]
+		}
+	}
+	err = m.store.EndTournament(context.TODO(), t.Id, results, false, now)
+	if errors.Is(err, store.ErrTournamentClosed) {
+		return nil
+	}
+	return err
+}
+
+func (m *module) startTournaments() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(tournamentTick)
+		defer ticker.Stop()
+		for {
+			m.tickTournaments(time.Now())
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// tickTournaments ends tournaments that are due, refreshes the standings of
+// running ones and posts any results not yet announced
+func (m *module) tickTournaments(now time.Time) {
+	open, err := m.store.OpenTournaments(context.TODO(), 0)
+	if err != nil {
+		log.Printf("failed to load tournaments: %v", err)
+		return
+	}
+	for _, t := range open {
+		if !m.handlesGuild(t.GuildId) {
+			continue
+		}
+		switch {
+		case !now.Before(t.EndsAt):
+			if err := m.finishTournament(t, now); err != nil {
+				log.Printf("failed to end tournament %d: %v", t.Id, err)
+			}
+		case !now.Before(t.StartsAt):
+			m.refreshStandings(t, now)
+		}
+	}
+	m.announceTournaments()
+}
+
+func (m *module) refreshStandings(t store.Tournament, now time.Time) {
+	if t.StandingsMsgId == 0 {
+		return
+	}
+	standings, entrants, err := m.standings(t)
+	if err != nil {
+		log.Printf("failed to load standings for tournament %d: %v", t.Id, err)
+		return
+	}
+	_, err = m.s.ChannelMessageEditEmbed(strconv.FormatInt(t.ChannelId, 10), strconv.FormatInt(t.StandingsMsgId, 10),
+		m.tournamentEmbed(t, standings, entrants, now))
+	if err != nil {
+		logREST(fmt.Sprintf("failed to update standings for tournament %d", t.Id), err)
+		// The message is gone; stop trying to edit it
+		if !retryableREST(err) {
+			_ = m.store.SetStandingsMessage(context.TODO(), t.Id, 0)
+		}
+	}
+}
+
+func (m *module) announceTournaments() {
+	ended, err := m.store.UnannouncedTournaments(context.TODO())
+	if err != nil {
+		log.Printf("failed to load ended tournaments: %v", err)
+		return
+	}
+	for _, t := range ended {
+		if m.handlesGuild(t.GuildId) {
+			m.announceTournament(t)
+		}
+	}
+}
+
+func (m *module) announceTournament(t store.Tournament) {
+	results, err := m.store.TournamentResults(context.TODO(), t.Id)
+	if err != nil {
+		log.Printf("failed to load results for tournament %d: %v", t.Id, err)
+		return
+	}
+	embed := m.tournamentResultsEmbed(t, results)
+	channelId := strconv.FormatInt(t.ChannelId, 10)
+
+	// Freeze the live standings on the final results too
+	if t.StandingsMsgId != 0 {
+		if _, err := m.s.ChannelMessageEditEmbed(channelId, strconv.FormatInt(t.StandingsMsgId, 10), embed); err != nil {
+			logREST(fmt.Sprintf("failed to finalize standings for tournament %d", t.Id), err)
+		}
+	}
+
+	var mentions []string
+	for _, r := range results {
+		if r.Rank <= len(fish.TournamentPrizeSplit) {
+			mentions = append(mentions, strconv.FormatInt(r.UserId, 10))
+		}
+	}
+	msg := &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: mentions},
+	}
+	if len(mentions) > 0 {
+		msg.Content = "<@" + strings.Join(mentions, "> <@") + ">"
+	}
+	if _, err := m.s.ChannelMessageSendComplex(channelId, msg); err != nil {
+		logREST(fmt.Sprintf("failed to announce tournament %d", t.Id), err)
+		if retryableREST(err) {
+			return
+		}
+	}
+	if err := m.store.MarkTournamentAnnounced(context.TODO(), t.Id); err != nil {
+		log.Printf("failed to mark tournament %d announced: %v", t.Id, err)
+	}
+}
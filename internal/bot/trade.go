@@ -0,0 +1,478 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/store"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// Trades expire well inside the 15 minute lifetime of the interaction token
+// so the original message can still be edited when they do.
+const tradeTimeout = 10 * time.Minute
+
+// tradeStaleAfter is when an open trade is certainly orphaned: its session
+// would have timed it out already. The extra minute leaves the owning
+// session time to do that itself.
+const tradeStaleAfter = tradeTimeout + time.Minute
+
+// What's on offer lives in the store (coins are already escrowed there); a
+// session only tracks who has confirmed and when the trade times out.
+type tradeSession struct {
+	id          int64
+	guildId     string
+	initiator   string
+	partner     string
+	names       map[string]string
+	interaction *discordgo.Interaction
+	timer       *time.Timer
+
+	mu        sync.Mutex
+	confirmed map[string]bool
+	closed    bool
+}
+
+type tradeSessions struct {
+	mu   sync.Mutex
+	open map[int64]*tradeSession
+}
+
+func newTradeSessions() *tradeSessions {
+	return &tradeSessions{open: make(map[int64]*tradeSession)}
+}
+
+func (t *tradeSessions) get(id int64) (*tradeSession, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ts, ok := t.open[id]
+	return ts, ok
+}
+
+func (t *tradeSessions) remove(id int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ts, ok := t.open[id]; ok {
+		ts.timer.Stop()
+		delete(t.open, id)
+	}
+}
+
+// stop cancels every pending timeout. Open trades are expired (and escrow
+// refunded) by the store the next time the bot starts.
+func (t *tradeSessions) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, ts := range t.open {
+		ts.timer.Stop()
+	}
+}
+
+func (ts *tradeSession) isParticipant(userId string) bool {
+	return userId == ts.initiator || userId == ts.partner
+}
+
+func (m *module) handleTrade(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/trade must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	var partner *discordgo.User
+	for _, opt := range data.Options {
+		if opt.Name == "user" && data.Resolved != nil {
+			partner = data.Resolved.Users[opt.Value.(string)]
+		}
+	}
+
+	userId := interactionUserId(i)
+	switch {
+	case partner == nil:
+		respondEphemeral(s, i, "Choose someone to trade with.")
+		return
+	case partner.Bot:
+		respondEphemeral(s, i, "Bots don't fish.")
+		return
+	case partner.ID == userId:
+		respondEphemeral(s, i, "You can't trade with yourself.")
+		return
+	}
+
+	id, err := m.store.CreateTrade(context.TODO(), toInt64(i.GuildID), toInt64(i.ChannelID), toInt64(userId), toInt64(partner.ID))
+	if err != nil {
+		logREST("failed to create trade", err)
+		respondEphemeral(s, i, "Error starting the trade.")
+		return
+	}
+
+	username := i.Member.Nick
+	if username == "" {
+		username = i.Member.User.Username
+	}
+	partnerName := partner.GlobalName
+	if member, ok := data.Resolved.Members[partner.ID]; ok && member.Nick != "" {
+		partnerName = member.Nick
+	}
+	if partnerName == "" {
+		partnerName = partner.Username
+	}
+
+	ts := &tradeSession{
+		id:          id,
+		guildId:     i.GuildID,
+		initiator:   userId,
+		partner:     partner.ID,
+		names:       map[string]string{userId: username, partner.ID: partnerName},
+		interaction: i.Interaction,
+		confirmed:   make(map[string]bool),
+	}
+	ts.timer = time.AfterFunc(tradeTimeout, func() { m.expireTrade(ts) })
+
+	m.trades.mu.Lock()
+	m.trades.open[id] = ts
+	m.trades.mu.Unlock()
+
+	embed := m.renderTrade(ts, "", "")
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("<@%s>, %s wants to trade with you!", partner.ID, username),
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: tradeComponents(id),
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Users: []string{partner.ID},
+			},
+		},
+	})
+	if err != nil {
+		logREST("failed to respond to trade", err)
+		m.closeTrade(ts, store.TradeCancelled)
+	}
+}
+
+func (m *module) handleTradeComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	action, ts, ok := m.tradeFromCustomId(s, i, i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	userId := interactionUserId(i)
+
+	switch action {
+	case "catches":
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseModal,
+			Data: &discordgo.InteractionResponseData{
+				CustomID: fmt.Sprintf("trade|catches|%d", ts.id),
+				Title:    "Offer catches",
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "ids",
+							Label:       "Catch numbers (see /inventory)",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "12, 15, 31",
+							Required:    true,
+							MaxLength:   200,
+						},
+					}},
+				},
+			},
+		})
+	case "coins":
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseModal,
+			Data: &discordgo.InteractionResponseData{
+				CustomID: fmt.Sprintf("trade|coins|%d", ts.id),
+				Title:    "Offer coins",
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "amount",
+							Label:       "Amount of 🪙 to add",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "100",
+							Required:    true,
+							MaxLength:   12,
+						},
+					}},
+				},
+			},
+		})
+	case "confirm":
+		ts.mu.Lock()
+		if ts.closed {
+			ts.mu.Unlock()
+			respondEphemeral(s, i, "This trade is already over.")
+			return
+		}
+		ts.confirmed[userId] = true
+		both := ts.confirmed[ts.initiator] && ts.confirmed[ts.partner]
+		if both {
+			ts.closed = true
+		}
+		ts.mu.Unlock()
+
+		if !both {
+			m.updateTradeMessage(s, i, ts, "", "", tradeComponents(ts.id))
+			return
+		}
+
+		m.trades.remove(ts.id)
+		err := m.store.ExecuteTrade(context.TODO(), ts.id)
+		switch {
+		case err == nil:
+			m.updateTradeMessage(s, i, ts, "complete", "", nil)
+		case errors.Is(err, store.ErrTradeItemGone):
+			if err := m.store.CloseTrade(context.TODO(), ts.id, store.TradeCancelled); err != nil {
+				logREST("failed to cancel trade", err)
+			}
+			m.updateTradeMessage(s, i, ts, "stale", "", nil)
+		default:
+			logREST("failed to execute trade", err)
+			if err := m.store.CloseTrade(context.TODO(), ts.id, store.TradeCancelled); err != nil {
+				logREST("failed to cancel trade", err)
+			}
+			m.updateTradeMessage(s, i, ts, "failed", "", nil)
+		}
+	case "cancel":
+		if !m.closeTrade(ts, store.TradeCancelled) {
+			respondEphemeral(s, i, "This trade is already over.")
+			return
+		}
+		m.updateTradeMessage(s, i, ts, "cancelled", ts.names[userId], nil)
+	}
+}
+
+func (m *module) handleTradeModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	action, ts, ok := m.tradeFromCustomId(s, i, data.CustomID)
+	if !ok {
+		return
+	}
+	userId := toInt64(interactionUserId(i))
+
+	value := ""
+	if len(data.Components) > 0 {
+		if row, ok := data.Components[0].(*discordgo.ActionsRow); ok && len(row.Components) > 0 {
+			if input, ok := row.Components[0].(*discordgo.TextInput); ok {
+				value = input.Value
+			}
+		}
+	}
+
+	var problems []string
+	switch action {
+	case "catches":
+		for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			catchId, err := strconv.ParseInt(strings.TrimPrefix(field, "#"), 10, 64)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("'%s' isn't a catch number", field))
+				continue
+			}
+			err = m.store.AddTradeCatch(context.TODO(), ts.id, userId, catchId)
+			switch {
+			case err == nil:
+			case errors.Is(err, store.ErrCatchNotOwned):
+				problems = append(problems, fmt.Sprintf("you don't have an unsold catch #%d", catchId))
+			case errors.Is(err, store.ErrTradeClosed):
+				respondEphemeral(s, i, "This trade is already over.")
+				return
+			default:
+				logREST("failed to offer catch", err)
+				problems = append(problems, fmt.Sprintf("couldn't add catch #%d", catchId))
+			}
+		}
+	case "coins":
+		amount, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || amount <= 0 {
+			respondEphemeral(s, i, "Enter a positive whole number of coins.")
+			return
+		}
+		err = m.store.EscrowCoins(context.TODO(), ts.id, userId, amount)
+		switch {
+		case err == nil:
+		case errors.Is(err, store.ErrInsufficientFunds):
+			respondEphemeral(s, i, fmt.Sprintf("You don't have %d 🪙.", amount))
+			return
+		case errors.Is(err, store.ErrTradeClosed):
+			respondEphemeral(s, i, "This trade is already over.")
+			return
+		default:
+			logREST("failed to escrow coins", err)
+			respondEphemeral(s, i, "Error adding coins to the trade.")
+			return
+		}
+	}
+
+	// Any change to the offer needs both sides to look again
+	ts.mu.Lock()
+	clear(ts.confirmed)
+	ts.mu.Unlock()
+
+	m.updateTradeMessage(s, i, ts, "", "", tradeComponents(ts.id))
+
+	if len(problems) > 0 {
+		if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: "Some catches weren't added: " + strings.Join(problems, "; ") + ".",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}); err != nil {
+			logREST("followup failed", err)
+		}
+	}
+}
+
+// tradeFromCustomId parses "trade|<action>|<id>" and checks the user is part
+// of the still-open trade, replying to them if not
+func (m *module) tradeFromCustomId(s *discordgo.Session, i *discordgo.InteractionCreate, customId string) (string, *tradeSession, bool) {
+	parts := strings.Split(customId, "|")
+	if len(parts) != 3 || parts[0] != "trade" {
+		return "", nil, false
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", nil, false
+	}
+
+	ts, ok := m.trades.get(id)
+	if !ok {
+		respondEphemeral(s, i, "This trade is already over.")
+		return "", nil, false
+	}
+	if !ts.isParticipant(interactionUserId(i)) {
+		respondEphemeral(s, i, "This isn't your trade - use `/trade` to start one.")
+		return "", nil, false
+	}
+	return parts[1], ts, true
+}
+
+// closeTrade ends a trade without executing it and refunds escrow. Returns
+// false if the trade was already closed by someone else.
+func (m *module) closeTrade(ts *tradeSession, status string) bool {
+	ts.mu.Lock()
+	if ts.closed {
+		ts.mu.Unlock()
+		return false
+	}
+	ts.closed = true
+	ts.mu.Unlock()
+
+	m.trades.remove(ts.id)
+	if err := m.store.CloseTrade(context.TODO(), ts.id, status); err != nil && !errors.Is(err, store.ErrTradeClosed) {
+		logREST("failed to close trade", err)
+	}
+	return true
+}
+
+func (m *module) expireTrade(ts *tradeSession) {
+	if !m.closeTrade(ts, store.TradeExpired) {
+		return
+	}
+	embed := m.renderTrade(ts, "expired", "")
+	empty := []discordgo.MessageComponent{}
+	if _, err := m.s.InteractionResponseEdit(ts.interaction, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &empty,
+	}); err != nil {
+		logREST("failed to edit expired trade", err)
+	}
+}
+
+// updateTradeMessage redraws the trade. status and cancelledBy are as in
+// view.TradeView.
+func (m *module) updateTradeMessage(s *discordgo.Session, i *discordgo.InteractionCreate, ts *tradeSession, status, cancelledBy string, components []discordgo.MessageComponent) {
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{m.renderTrade(ts, status, cancelledBy)},
+			Components: components,
+		},
+	})
+}
+
+func tradeComponents(id int64) []discordgo.MessageComponent {
+	customId := func(action string) string { return fmt.Sprintf("trade|%s|%d", action, id) }
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Offer catches", Style: discordgo.SecondaryButton, CustomID: customId("catches")},
+			discordgo.Button{Label: "Offer coins", Style: discordgo.SecondaryButton, CustomID: customId("coins")},
+			discordgo.Button{Label: "Confirm", Style: discordgo.SuccessButton, CustomID: customId("confirm")},
+			discordgo.Button{Label: "Cancel", Style: discordgo.DangerButton, CustomID: customId("cancel")},
+		}},
+	}
+}
+
+func (m *module) renderTrade(ts *tradeSession, status, cancelledBy string) *discordgo.MessageEmbed {
+	guildId := toInt64(ts.guildId)
+	items, err := m.store.TradeItems(context.TODO(), ts.id)
+	if err != nil {
+		logREST("failed to load trade items", err)
+	}
+
+	// Offered catches are in one of the two inventories, before or after the
+	// trade executes
+	catches := make(map[int64]fish.Catch)
+	for _, uid := range []string{ts.initiator, ts.partner} {
+		cs, err := m.store.UnsoldCatches(context.TODO(), guildId, toInt64(uid), -1)
+		if err != nil {
+			logREST("failed to load catches", err)
+		}
+		for _, c := range cs {
+			catches[c.Id] = c
+		}
+	}
+
+	ts.mu.Lock()
+	confirmed := map[string]bool{ts.initiator: ts.confirmed[ts.initiator], ts.partner: ts.confirmed[ts.partner]}
+	ts.mu.Unlock()
+
+	factors := m.market.factors(guildId)
+	v := view.TradeView{Id: ts.id, Initiator: ts.initiator, Partner: ts.partner, Status: status, CancelledBy: cancelledBy}
+	for _, uid := range []string{ts.initiator, ts.partner} {
+		offer := view.TradeOffer{Name: ts.names[uid], Confirmed: confirmed[uid]}
+		for _, it := range items {
+			if it.FromUser != toInt64(uid) {
+				continue
+			}
+			if it.Coins > 0 {
+				offer.Coins += it.Coins
+				continue
+			}
+			c, ok := catches[it.CatchId]
+			if !ok {
+				offer.Items = append(offer.Items, view.TradeItem{CatchId: it.CatchId, Gone: true})
+				continue
+			}
+			sp, _ := m.reg.GetById(c.SpeciesId)
+			offer.Items = append(offer.Items, view.TradeItem{CatchId: c.Id, Species: sp.Name, Size: c.Size, Value: m.priceOf(c, factors)})
+		}
+		v.Offers = append(v.Offers, offer)
+	}
+	return view.TradeEmbed(v, m.settings.templates(guildId))
+}
+
+// expireStaleTrades refunds trades left open by a previous run. Other shards
+// share the store, so only trades past their timeout are touched: any that
+// are younger may still have a live session somewhere.
+func expireStaleTrades(st *store.SQLiteStore) {
+	n, err := st.ExpireOpenTrades(context.TODO(), time.Now().Add(-tradeStaleAfter))
+	if err != nil {
+		log.Printf("failed to expire stale trades: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("expired %d trades left open by a previous run", n)
+	}
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/faideww/chat-fishing/internal/fish"
+	"github.com/faideww/chat-fishing/internal/view"
+)
+
+// forecastPeriods is how far ahead /forecast looks, current period included
+const forecastPeriods = 8
+
+// forecastPeriod describes a weather period and the tags it favours
+func forecastPeriod(p fish.Forecast) view.ForecastPeriod {
+	boosts := p.Weather.Boosts()
+	tags := make([]string, 0, len(boosts))
+	for tag := range boosts {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	out := view.ForecastPeriod{
+		Weather: p.Weather.String(),
+		Emoji:   p.Weather.Emoji(),
+		Start:   p.Start.Unix(),
+		End:     p.End.Unix(),
+	}
+	for _, tag := range tags {
+		out.Boosts = append(out.Boosts, view.WeatherBoost{Tag: strings.ReplaceAll(tag, "_", " "), Percent: boosts[tag] * 100})
+	}
+	return out
+}
+
+func (m *module) handleForecast(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Validate execution context (/forecast must be run in a server)
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Use this command in a server!")
+		return
+	}
+
+	periods := m.weather.Forecast(toInt64(i.GuildID), forecastPeriods)
+	v := view.ForecastView{Now: forecastPeriod(periods[0])}
+	for _, p := range periods[1:] {
+		v.Upcoming = append(v.Upcoming, forecastPeriod(p))
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{view.ForecastEmbed(v, m.settings.templates(toInt64(i.GuildID)))},
+		},
+	})
+}
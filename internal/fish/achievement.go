@@ -0,0 +1,156 @@
+package fish
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Achievement is a goal defined in data. Exactly one kind of goal applies:
+//   - Count: catch this many fish matching the filters (the default, 1)
+//   - Every: catch one of every species matching the filters
+//   - StreakDays: catch a fish on this many days in a row
+//   - Cost: redeem this many conservation points (never unlocked by catches)
+//
+// The CatchFilter narrows which catches count. Unlocking an achievement with
+// a Title lets the user equip it.
+type Achievement struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Title       string `json:"title"`
+
+	Count      int   `json:"count"`
+	Every      bool  `json:"every"`
+	StreakDays int   `json:"streakDays"`
+	Cost       int64 `json:"cost"`
+
+	CatchFilter
+}
+
+type AchievementCatalog struct {
+	items []Achievement
+	byKey map[string]int
+}
+
+func LoadAchievementsFromJSON(path string, reg *Registry) (*AchievementCatalog, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Achievement
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]int, len(items))
+	for i := range items {
+		a := &items[i]
+		if a.Key == "" {
+			return nil, fmt.Errorf("missing key at index %d", i)
+		}
+		if _, dup := byKey[a.Key]; dup {
+			return nil, fmt.Errorf("duplicate achievement key %q", a.Key)
+		}
+
+		kinds := 0
+		for _, set := range []bool{a.Count > 0, a.Every, a.StreakDays > 0, a.Cost > 0} {
+			if set {
+				kinds++
+			}
+		}
+		switch {
+		case kinds > 1:
+			return nil, fmt.Errorf("achievement %q sets more than one of count, every, streakDays and cost", a.Key)
+		case a.Cost > 0 && a.Title == "":
+			return nil, fmt.Errorf("achievement %q has a cost but no title to redeem", a.Key)
+		case kinds == 0:
+			a.Count = 1
+		}
+
+		if err := a.CatchFilter.compile(reg); err != nil {
+			return nil, fmt.Errorf("achievement %q: %w", a.Key, err)
+		}
+		byKey[a.Key] = i
+	}
+
+	return &AchievementCatalog{items: items, byKey: byKey}, nil
+}
+
+func (c *AchievementCatalog) Get(key string) (Achievement, bool) {
+	i, ok := c.byKey[key]
+	if !ok {
+		return Achievement{}, false
+	}
+	return c.items[i], true
+}
+
+func (c *AchievementCatalog) All() []Achievement {
+	out := make([]Achievement, len(c.items))
+	copy(out, c.items)
+	return out
+}
+
+// CatchTally counts a user's catches of each species by size class
+type CatchTally map[SpeciesId][SizeEnormous + 1]int
+
+// count is how many tallied catches of sp pass the filter
+func (f CatchFilter) count(p *Picker, sp Species, tally CatchTally) int {
+	if !f.MatchesSpecies(p, sp) {
+		return 0
+	}
+	n := 0
+	for c, k := range tally[sp.Id] {
+		if f.size < 0 || SizeClass(c) >= f.size {
+			n += k
+		}
+	}
+	return n
+}
+
+// Progress measures the user's catches against the achievement: tally counts
+// every catch they've made, and active holds times with a catch (see
+// LongestStreak), counting days in loc. It is unlocked once have >= need.
+func (a Achievement) Progress(p *Picker, tally CatchTally, active []time.Time, loc *time.Location) (have, need int) {
+	switch {
+	case a.Cost > 0:
+		return 0, 0
+
+	case a.StreakDays > 0:
+		return min(LongestStreak(active, loc), a.StreakDays), a.StreakDays
+
+	case a.Every:
+		for _, sp := range p.reg.All() {
+			if !a.MatchesSpecies(p, sp) {
+				continue
+			}
+			need++
+			if a.count(p, sp, tally) > 0 {
+				have++
+			}
+		}
+		return have, need
+
+	default:
+		for _, sp := range p.reg.All() {
+			have += a.count(p, sp, tally)
+		}
+		return min(have, a.Count), a.Count
+	}
+}
+
+// AdvancedBy reports whether landing c can have moved the user towards the
+// achievement, so only those need measuring after a catch. newDay is whether
+// c was their first catch of the day, the only way a streak grows.
+func (a Achievement) AdvancedBy(p *Picker, c Catch, newDay bool) bool {
+	switch {
+	case a.Cost > 0:
+		return false
+	case a.StreakDays > 0:
+		return newDay
+	default:
+		return a.Matches(p, c)
+	}
+}
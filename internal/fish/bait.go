@@ -0,0 +1,73 @@
+package fish
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Bait is a consumable: one is used per cast, temporarily raising the weight
+// of species with matching tags.
+type Bait struct {
+	Key         string             `json:"key"`
+	Name        string             `json:"name"`
+	Price       int64              `json:"price"` // per pack
+	Pack        int                `json:"pack"`  // how many a purchase gives
+	Description string             `json:"description"`
+	TagBoosts   map[string]float64 `json:"tagBoosts"` // tag -> +x weight (3.0 = +300%)
+}
+
+type BaitCatalog struct {
+	items []Bait
+	byKey map[string]int
+}
+
+func LoadBaitFromJSON(path string) (*BaitCatalog, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Bait
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]int, len(items))
+	for i, b := range items {
+		if b.Key == "" {
+			return nil, fmt.Errorf("missing key at index %d", i)
+		}
+		if _, dup := byKey[b.Key]; dup {
+			return nil, fmt.Errorf("duplicate bait key %q", b.Key)
+		}
+		if b.Price < 0 {
+			return nil, fmt.Errorf("bait %q has a negative price", b.Key)
+		}
+		if b.Pack < 1 {
+			items[i].Pack = 1
+		}
+		byKey[b.Key] = i
+	}
+
+	return &BaitCatalog{items: items, byKey: byKey}, nil
+}
+
+func (c *BaitCatalog) Get(key string) (Bait, bool) {
+	i, ok := c.byKey[key]
+	if !ok {
+		return Bait{}, false
+	}
+	return c.items[i], true
+}
+
+func (c *BaitCatalog) All() []Bait {
+	out := make([]Bait, len(c.items))
+	copy(out, c.items)
+	return out
+}
+
+// With returns mods with the bait's tag boosts added. mods is not modified.
+func (b Bait) With(mods Modifiers) Modifiers {
+	return mods.WithTagBoosts(b.TagBoosts)
+}
@@ -12,4 +12,5 @@ type Catch struct {
 	Species   string
 	Size      float64
 	CaughtAt  time.Time
+	Released  bool // let go after landing: kept for records, not inventory
 }
@@ -0,0 +1,16 @@
+package fish
+
+// ReleasePoints is how many conservation points letting a fish go earns.
+// Rarer and bigger fish are worth more; a released fish still counts for the
+// fishbook and records, so points are the only thing given up in exchange
+// for the coins it would have sold for.
+func ReleasePoints(t RarityTier, c SizeClass) int64 {
+	pts := int64(t) + 1
+	switch {
+	case c >= SizeEnormous:
+		pts *= 3
+	case c >= SizeHuge:
+		pts *= 2
+	}
+	return pts
+}
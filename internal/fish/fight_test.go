@@ -0,0 +1,90 @@
+package fish
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func seeded() *rand.Rand { return rand.New(rand.NewPCG(1, 2)) }
+
+func always(a FightAction) func(*Fight) FightAction {
+	return func(*Fight) FightAction { return a }
+}
+
+// careful reels until the line is tight, then gives slack
+func careful(f *Fight) FightAction {
+	if f.Tension > 0.7 {
+		return FightSlack
+	}
+	return FightReel
+}
+
+func TestFightOutcomes(t *testing.T) {
+	tests := []struct {
+		name   string
+		fight  *Fight
+		policy func(*Fight) FightAction
+		want   FightState
+	}{
+		{"reeling a mythic on bare line snaps", NewFight(TierMythic, 1, 0, seeded()), always(FightReel), FightSnapped},
+		{"all slack throws the hook", NewFight(TierCommon, 0.5, 0, seeded()), always(FightSlack), FightThrown},
+		{"careful play lands it", NewFight(TierRare, 0.5, 0.5, seeded()), careful, FightLanded},
+		{"strong line lands a mythic", NewFight(TierMythic, 1, maxLineStrength, seeded()), careful, FightLanded},
+		{"running out all the line spools", &Fight{Tension: 0.9, Distance: FightMaxDistance - 1, Power: 1, rng: seeded()}, always(FightSlack), FightSpooled},
+		{"running out of steps spools", &Fight{Tension: 0.5, Distance: 40, Power: 1, Steps: FightMaxSteps - 1, rng: seeded()}, always(FightReel), FightSpooled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.fight
+			for f.State == FightOn {
+				if f.Steps > FightMaxSteps {
+					t.Fatalf("fight still on after %d steps", f.Steps)
+				}
+				f.Step(tt.policy(f))
+			}
+			if f.State != tt.want {
+				t.Errorf("fight ended %v after %d steps (tension %.2f, distance %.1f), want %v",
+					f.State, f.Steps, f.Tension, f.Distance, tt.want)
+			}
+			if f.Tension < 0 || f.Tension > 1 || f.Distance < 0 {
+				t.Errorf("out of range: tension %.2f, distance %.1f", f.Tension, f.Distance)
+			}
+		})
+	}
+}
+
+func TestFightIsDeterministic(t *testing.T) {
+	a, b := NewFight(TierEpic, 0.8, 0.3, seeded()), NewFight(TierEpic, 0.8, 0.3, seeded())
+	for a.State == FightOn {
+		a.Step(careful(a))
+		b.Step(careful(b))
+		if snapshot(a) != snapshot(b) {
+			t.Fatalf("fights diverged at step %d", a.Steps)
+		}
+	}
+}
+
+// snapshot is f without its random source, for comparing
+func snapshot(f *Fight) Fight {
+	c := *f
+	c.rng = nil
+	return c
+}
+
+func TestStepAfterFightEnds(t *testing.T) {
+	f := NewFight(TierCommon, 0, 0, seeded())
+	for f.Step(FightSlack) == FightOn {
+	}
+	before := *f
+	if got := f.Step(FightReel); got != before.State || *f != before {
+		t.Errorf("stepping a finished fight changed it: %+v, want %+v", *f, before)
+	}
+}
+
+func TestNeedsFight(t *testing.T) {
+	for c := SizeTiny; c <= SizeEnormous; c++ {
+		if got, want := NeedsFight(c), c >= SizeHuge; got != want {
+			t.Errorf("NeedsFight(%v) = %v, want %v", c, got, want)
+		}
+	}
+}
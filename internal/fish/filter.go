@@ -0,0 +1,105 @@
+package fish
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CatchFilter is the shared predicate for data-defined goals (achievements,
+// quests). Every field is optional and they combine:
+//   - Tier: exact rarity, MinTier: that rarity or rarer ("Rare", "Mythic")
+//   - Size: minimum size class as shown in embeds ("big", "enormous")
+//   - Species: a species key, Tag: a species tag ("river", "shark")
+//
+// compile must be called after loading before the filter is used.
+type CatchFilter struct {
+	Tier    string `json:"tier"`
+	MinTier string `json:"minTier"`
+	Size    string `json:"size"`
+	Species string `json:"species"`
+	Tag     string `json:"tag"`
+
+	tier    RarityTier // -1 for any
+	minTier RarityTier // -1 for any
+	size    SizeClass  // minimum, -1 for any
+	species SpeciesId  // -1 for any
+}
+
+// ParseRarityTier is the inverse of RarityTier.String, ignoring case
+func ParseRarityTier(s string) (RarityTier, bool) {
+	for t := TierCommon; t <= TierMythic; t++ {
+		if strings.EqualFold(s, t.String()) {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// ParseSizeClass is the inverse of SizeClass.String, ignoring case
+func ParseSizeClass(s string) (SizeClass, bool) {
+	for c := SizeTiny; c <= SizeEnormous; c++ {
+		if strings.EqualFold(s, c.String()) {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+func (f *CatchFilter) compile(reg *Registry) error {
+	f.tier, f.minTier, f.size, f.species = -1, -1, -1, -1
+	if f.Tier != "" {
+		t, ok := ParseRarityTier(f.Tier)
+		if !ok {
+			return fmt.Errorf("unknown tier %q", f.Tier)
+		}
+		f.tier = t
+	}
+	if f.MinTier != "" {
+		t, ok := ParseRarityTier(f.MinTier)
+		if !ok {
+			return fmt.Errorf("unknown tier %q", f.MinTier)
+		}
+		f.minTier = t
+	}
+	if f.Size != "" {
+		c, ok := ParseSizeClass(f.Size)
+		if !ok {
+			return fmt.Errorf("unknown size %q", f.Size)
+		}
+		f.size = c
+	}
+	if f.Species != "" {
+		id, ok := reg.IdByKey(f.Species)
+		if !ok {
+			return fmt.Errorf("unknown species %q", f.Species)
+		}
+		f.species = id
+	}
+	return nil
+}
+
+// MatchesSpecies checks the parts of the filter that don't depend on the
+// individual catch
+func (f CatchFilter) MatchesSpecies(p *Picker, sp Species) bool {
+	if f.species >= 0 && sp.Id != f.species {
+		return false
+	}
+	if f.tier >= 0 && p.SpeciesTier(sp.Id) != f.tier {
+		return false
+	}
+	if f.minTier >= 0 && p.SpeciesTier(sp.Id) < f.minTier {
+		return false
+	}
+	if f.Tag != "" && !sp.HasTag(f.Tag) {
+		return false
+	}
+	return true
+}
+
+func (f CatchFilter) Matches(p *Picker, c Catch) bool {
+	sp, ok := p.reg.GetById(c.SpeciesId)
+	if !ok || !f.MatchesSpecies(p, sp) {
+		return false
+	}
+	return f.size < 0 || SizeClassFor(sp, c.Size) >= f.size
+}
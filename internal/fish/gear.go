@@ -0,0 +1,98 @@
+package fish
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type GearSlot string
+
+const (
+	SlotRod  GearSlot = "rod"
+	SlotReel GearSlot = "reel"
+	SlotLine GearSlot = "line"
+)
+
+// Gear is an item from the shop catalog. Each piece occupies one slot of a
+// user's loadout and contributes to their roll Modifiers.
+type Gear struct {
+	Key               string   `json:"key"`
+	Name              string   `json:"name"`
+	Slot              GearSlot `json:"slot"`
+	Price             int64    `json:"price"`
+	Description       string   `json:"description"`
+	RarityBoost       float64  `json:"rarityBoost"`       // +x weight for Rare and above
+	SizeBiasShift     float64  `json:"sizeBiasShift"`     // subtracted from the species size bias
+	CooldownReduction float64  `json:"cooldownReduction"` // fraction of the fishing cooldown removed
+	LineStrength      float64  `json:"lineStrength"`      // slows tension build-up when fighting big fish
+}
+
+type GearCatalog struct {
+	items []Gear
+	byKey map[string]int
+}
+
+func LoadGearFromJSON(path string) (*GearCatalog, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Gear
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]int, len(items))
+	for i, g := range items {
+		if g.Key == "" {
+			return nil, fmt.Errorf("missing key at index %d", i)
+		}
+		if _, dup := byKey[g.Key]; dup {
+			return nil, fmt.Errorf("duplicate gear key %q", g.Key)
+		}
+		switch g.Slot {
+		case SlotRod, SlotReel, SlotLine:
+		default:
+			return nil, fmt.Errorf("gear %q has unknown slot %q", g.Key, g.Slot)
+		}
+		if g.Price < 0 {
+			return nil, fmt.Errorf("gear %q has a negative price", g.Key)
+		}
+		byKey[g.Key] = i
+	}
+
+	return &GearCatalog{items: items, byKey: byKey}, nil
+}
+
+func (c *GearCatalog) Get(key string) (Gear, bool) {
+	i, ok := c.byKey[key]
+	if !ok {
+		return Gear{}, false
+	}
+	return c.items[i], true
+}
+
+func (c *GearCatalog) All() []Gear {
+	out := make([]Gear, len(c.items))
+	copy(out, c.items)
+	return out
+}
+
+// Modifiers sums the effects of the given gear keys. Unknown keys (e.g. an
+// item removed from the catalog) are ignored.
+func (c *GearCatalog) Modifiers(keys ...string) Modifiers {
+	var mods Modifiers
+	for _, k := range keys {
+		g, ok := c.Get(k)
+		if !ok {
+			continue
+		}
+		mods.RarityBoost += g.RarityBoost
+		mods.SizeBiasShift += g.SizeBiasShift
+		mods.CooldownReduction += g.CooldownReduction
+		mods.LineStrength += g.LineStrength
+	}
+	return mods.clamped()
+}
@@ -0,0 +1,58 @@
+package fish
+
+import (
+	"testing"
+)
+
+func TestGearModifiers(t *testing.T) {
+	items := []Gear{
+		{Key: "rod", Slot: SlotRod, RarityBoost: 0.5, SizeBiasShift: 0.25},
+		{Key: "reel", Slot: SlotReel, CooldownReduction: 0.1, LineStrength: 0.2},
+		{Key: "line", Slot: SlotLine, RarityBoost: 0.25, LineStrength: 0.3},
+		{Key: "big-rod", Slot: SlotRod, RarityBoost: 5, CooldownReduction: 0.4, LineStrength: 1.5},
+		{Key: "cursed-reel", Slot: SlotReel, RarityBoost: -2, CooldownReduction: -0.5, LineStrength: -1},
+	}
+	cat, err := LoadGearFromJSON(writeJSON(t, "gear.json", items))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		keys []string
+		want Modifiers
+	}{
+		{"nothing equipped", nil, Modifiers{}},
+		{"one item", []string{"rod"}, Modifiers{RarityBoost: 0.5, SizeBiasShift: 0.25}},
+		{"full loadout sums", []string{"rod", "reel", "line"}, Modifiers{RarityBoost: 0.75, SizeBiasShift: 0.25, CooldownReduction: 0.1, LineStrength: 0.5}},
+		{"unknown keys ignored", []string{"rod", "retired-reel"}, Modifiers{RarityBoost: 0.5, SizeBiasShift: 0.25}},
+		{"caps apply", []string{"big-rod", "reel", "line", "rod"}, Modifiers{RarityBoost: maxRarityBoost, SizeBiasShift: 0.25, CooldownReduction: maxCooldownReduction, LineStrength: maxLineStrength}},
+		{"never negative", []string{"cursed-reel"}, Modifiers{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cat.Modifiers(tt.keys...)
+			if got.RarityBoost != tt.want.RarityBoost || got.SizeBiasShift != tt.want.SizeBiasShift ||
+				got.CooldownReduction != tt.want.CooldownReduction || got.LineStrength != tt.want.LineStrength {
+				t.Errorf("Modifiers(%v) = %+v, want %+v", tt.keys, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCooldownScale(t *testing.T) {
+	if got := (Modifiers{CooldownReduction: 0.25}).CooldownScale(); got != 0.75 {
+		t.Errorf("CooldownScale() = %v, want 0.75", got)
+	}
+}
+
+func TestWithTagBoostsDoesNotShare(t *testing.T) {
+	base := Modifiers{TagBoosts: map[string]float64{"night": 0.5}}
+	boosted := base.WithTagBoosts(map[string]float64{"night": 0.25, "rain": 1})
+	if base.TagBoosts["night"] != 0.5 || len(base.TagBoosts) != 1 {
+		t.Errorf("WithTagBoosts changed the receiver: %v", base.TagBoosts)
+	}
+	if boosted.TagBoosts["night"] != 0.75 || boosted.TagBoosts["rain"] != 1 {
+		t.Errorf("WithTagBoosts = %v, want night 0.75 and rain 1", boosted.TagBoosts)
+	}
+}
@@ -0,0 +1,66 @@
+package fish
+
+import "math"
+
+// Dynamic pricing: every sale of a species adds to its supply, and supply
+// decays with a half-life so prices drift back toward the base once people
+// stop dumping that fish on the market.
+const (
+	MarketWindowDays   = 7
+	marketHalfLifeDays = 1.5
+	marketFloor        = 0.25 // prices never drop below 25% of base
+)
+
+// MarketElasticity is roughly how many recent sales halve the price of a
+// species. Rarer fish are sold less often, so each sale moves them more.
+func MarketElasticity(t RarityTier) float64 {
+	switch t {
+	case TierMythic:
+		return 1.5
+	case TierLegendary:
+		return 3
+	case TierEpic:
+		return 6
+	case TierRare:
+		return 12
+	case TierUncommon:
+		return 25
+	default:
+		return 40
+	}
+}
+
+// Supply collapses daily sale counts (index 0 is today, 1 is yesterday, ...)
+// into a single decayed figure.
+func Supply(dailySales []int64) float64 {
+	total := 0.0
+	for age, n := range dailySales {
+		if age >= MarketWindowDays {
+			break
+		}
+		total += float64(n) * math.Pow(0.5, float64(age)/marketHalfLifeDays)
+	}
+	return total
+}
+
+// MarketFactor is the multiplier applied to a species' price given its
+// current supply.
+func MarketFactor(t RarityTier, supply float64) float64 {
+	if supply <= 0 {
+		return 1
+	}
+	f := 1 / (1 + supply/MarketElasticity(t))
+	if f < marketFloor {
+		f = marketFloor
+	}
+	return f
+}
+
+// MarketPrice is Price scaled by a market factor
+func MarketPrice(t RarityTier, percentile, factor float64) int64 {
+	p := int64(math.Round(float64(Price(t, percentile)) * factor))
+	if p < 1 {
+		p = 1
+	}
+	return p
+}
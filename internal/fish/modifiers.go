@@ -0,0 +1,83 @@
+package fish
+
+// Caps so stacked gear can't break the game
+const (
+	maxCooldownReduction = 0.5
+	maxRarityBoost       = 5.0
+	maxLineStrength      = 2.0
+)
+
+// Modifiers adjust a single cast. The zero value changes nothing.
+type Modifiers struct {
+	// RarityBoost multiplies the weight of Rare-and-above species by
+	// (1 + RarityBoost).
+	RarityBoost float64
+	// SizeBiasShift is subtracted from a species' size bias exponent, so a
+	// positive shift flattens the distribution towards bigger fish. The
+	// effective bias never drops below 1 (uniform).
+	SizeBiasShift float64
+	// CooldownReduction is the fraction of the cooldown removed after the
+	// cast, in [0, 0.5].
+	CooldownReduction float64
+	// TagBoosts multiplies the weight of species carrying a tag by
+	// (1 + boost). A species matching several tags adds their boosts.
+	TagBoosts map[string]float64
+	// LineStrength divides how fast reeling raises line tension in a fight
+	// by (1 + LineStrength).
+	LineStrength float64
+}
+
+// WithTagBoosts returns a copy of m with boosts added to its tag boosts. The
+// receiver's map is never written to, so Modifiers can be shared safely.
+func (m Modifiers) WithTagBoosts(boosts map[string]float64) Modifiers {
+	if len(boosts) == 0 {
+		return m
+	}
+	merged := make(map[string]float64, len(m.TagBoosts)+len(boosts))
+	for tag, b := range m.TagBoosts {
+		merged[tag] = b
+	}
+	for tag, b := range boosts {
+		merged[tag] += b
+	}
+	m.TagBoosts = merged
+	return m
+}
+
+// weightFactor is the multiplier these modifiers apply to a species' weight
+func (m Modifiers) weightFactor(sp Species, tier RarityTier) float64 {
+	f := 1.0
+	if tier >= TierRare {
+		f *= 1 + m.RarityBoost
+	}
+	if len(m.TagBoosts) > 0 {
+		boost := 0.0
+		for _, tag := range sp.Tags {
+			boost += m.TagBoosts[tag]
+		}
+		if boost > 0 {
+			f *= 1 + boost
+		}
+	}
+	return f
+}
+
+// CooldownScale is the factor to apply to the fishing cooldown
+func (m Modifiers) CooldownScale() float64 {
+	return 1 - m.CooldownReduction
+}
+
+func (m Modifiers) clamped() Modifiers {
+	if m.CooldownReduction < 0 {
+		m.CooldownReduction = 0
+	} else if m.CooldownReduction > maxCooldownReduction {
+		m.CooldownReduction = maxCooldownReduction
+	}
+	if m.RarityBoost < 0 {
+		m.RarityBoost = 0
+	} else if m.RarityBoost > maxRarityBoost {
+		m.RarityBoost = maxRarityBoost
+	}
+	m.LineStrength = min(max(m.LineStrength, 0), maxLineStrength)
+	return m
+}
@@ -49,24 +49,57 @@ func NewPicker(reg *Registry, rng *mrand.Rand) *Picker {
 
 func (p *Picker) PickId() SpeciesId {
 	roll := p.rng.Intn(p.totalWeight) // random int from [0,totalWeight)
+	return SpeciesId(search(p.cumulative, roll))
+}
+
+// PickIdWith picks a species using weights adjusted by mods. The adjusted
+// table is built per call so the shared p.cumulative is never mutated.
+func (p *Picker) PickIdWith(mods Modifiers) SpeciesId {
+	if mods.RarityBoost == 0 && len(mods.TagBoosts) == 0 {
+		return p.PickId()
+	}
+
+	cumulative := make([]float64, len(p.cumulative))
+	total := 0.0
+	prev := 0
+	for i, c := range p.cumulative {
+		w := float64(c - prev)
+		prev = c
+		if sp, ok := p.reg.GetById(SpeciesId(i)); ok {
+			w *= mods.weightFactor(sp, p.SpeciesTier(sp.Id))
+		}
+		total += w
+		cumulative[i] = total
+	}
+
+	roll := p.rng.Float64() * total
+	return SpeciesId(search(cumulative, roll))
+}
 
-	// binary search for the species using p.cumulative
-	lo, hi := 0, len(p.cumulative)-1
+// search finds the first index whose cumulative weight exceeds roll
+func search[T int | float64](cumulative []T, roll T) int {
+	lo, hi := 0, len(cumulative)-1
 	for lo < hi {
 		mid := (lo + hi) >> 1
-		if roll < p.cumulative[mid] {
+		if roll < cumulative[mid] {
 			hi = mid
 		} else {
 			lo = mid + 1
 		}
 	}
-	return SpeciesId(lo)
+	return lo
 }
 
 // Sizes are determined by u^k, where u is a random value between 0 and 1
 // and k is the size bias of the species. A higher k means the fish tend
 // to be smaller, where as k = 1 is a uniform distribution.
 func (p *Picker) RollSize(id SpeciesId) float64 {
+	return p.RollSizeWith(id, Modifiers{})
+}
+
+// RollSizeWith rolls a size with the species' bias exponent lowered by
+// mods.SizeBiasShift (never below 1).
+func (p *Picker) RollSizeWith(id SpeciesId, mods Modifiers) float64 {
 	sp, ok := p.reg.GetById(id)
 	if !ok {
 		return 0
@@ -76,7 +109,7 @@ func (p *Picker) RollSize(id SpeciesId) float64 {
 		max = min
 	}
 	u := p.rng.Float64()
-	k := sp.SizeBias
+	k := sp.SizeBias - mods.SizeBiasShift
 	if k < 1 {
 		k = 1
 	}
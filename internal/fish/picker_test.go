@@ -0,0 +1,120 @@
+package fish
+
+import (
+	"encoding/json"
+	"math"
+	mrand "math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testSpecies are two common fish and a rare one
+var testSpecies = []SpeciesJSON{
+	{Id: 0, Key: "perch", Name: "Perch", Weight: 100, MinSize: 10, MaxSize: 30, SizeBias: 1},
+	{Id: 1, Key: "catfish", Name: "Catfish", Weight: 100, MinSize: 20, MaxSize: 120, SizeBias: 3, Tags: []string{"night"}},
+	{Id: 2, Key: "sturgeon", Name: "Sturgeon", Weight: 10, MinSize: 50, MaxSize: 250, SizeBias: 2},
+}
+
+func writeJSON(t *testing.T, name string, v any) string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testPicker(t *testing.T, seed int64) *Picker {
+	t.Helper()
+	reg, err := LoadRegistryFromJSON(writeJSON(t, "species.json", testSpecies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewPicker(reg, mrand.New(mrand.NewSource(seed)))
+}
+
+func TestPickIdWith(t *testing.T) {
+	const draws = 50000
+	tests := []struct {
+		name string
+		mods Modifiers
+		want [3]float64 // expected share of each species
+	}{
+		{"no modifiers", Modifiers{}, [3]float64{100.0 / 210, 100.0 / 210, 10.0 / 210}},
+		{"rarity boost", Modifiers{RarityBoost: 1}, [3]float64{100.0 / 220, 100.0 / 220, 20.0 / 220}},
+		{"tag boost", Modifiers{TagBoosts: map[string]float64{"night": 1}}, [3]float64{100.0 / 310, 200.0 / 310, 10.0 / 310}},
+		{"unmatched tag", Modifiers{TagBoosts: map[string]float64{"reef": 3}}, [3]float64{100.0 / 210, 100.0 / 210, 10.0 / 210}},
+		{"both", Modifiers{RarityBoost: 1, TagBoosts: map[string]float64{"night": 1}}, [3]float64{100.0 / 320, 200.0 / 320, 20.0 / 320}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPicker(t, 1)
+			var counts [3]int
+			for range draws {
+				counts[p.PickIdWith(tt.mods)]++
+			}
+			for id, want := range tt.want {
+				got := float64(counts[id]) / draws
+				if math.Abs(got-want) > 0.01 {
+					t.Errorf("species %d picked %.3f of the time, want %.3f", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPickIdWithoutModifiersMatchesPickId(t *testing.T) {
+	a, b := testPicker(t, 7), testPicker(t, 7)
+	for range 100 {
+		if x, y := a.PickId(), b.PickIdWith(Modifiers{}); x != y {
+			t.Fatalf("PickIdWith(zero) = %d, PickId = %d", y, x)
+		}
+	}
+}
+
+func TestRollSizeWith(t *testing.T) {
+	const rolls = 20000
+	tests := []struct {
+		name     string
+		id       SpeciesId
+		shift    float64
+		wantMean float64 // min + (max-min)/(k+1), the mean of u^k scaled
+	}{
+		{"uniform", 0, 0, 20},
+		{"biased", 1, 0, 20 + 100.0/4},
+		{"shifted", 1, 1, 20 + 100.0/3},
+		{"shift clamps to uniform", 1, 5, 70},
+		{"negative shift biases harder", 2, -1, 50 + 200.0/4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPicker(t, 3)
+			sp, _ := p.reg.GetById(tt.id)
+			sum := 0.0
+			for range rolls {
+				size := p.RollSizeWith(tt.id, Modifiers{SizeBiasShift: tt.shift})
+				if size < sp.MinSize || size > sp.MaxSize {
+					t.Fatalf("size %.1f outside [%.1f, %.1f]", size, sp.MinSize, sp.MaxSize)
+				}
+				if math.Abs(size*10-math.Round(size*10)) > 1e-9 {
+					t.Fatalf("size %v isn't rounded to the mm", size)
+				}
+				sum += size
+			}
+			if mean := sum / rolls; math.Abs(mean-tt.wantMean) > 0.02*(sp.MaxSize-sp.MinSize) {
+				t.Errorf("mean size %.2f, want about %.2f", mean, tt.wantMean)
+			}
+		})
+	}
+}
+
+func TestRollSizeUnknownSpecies(t *testing.T) {
+	if size := testPicker(t, 1).RollSize(99); size != 0 {
+		t.Errorf("RollSize(unknown) = %v, want 0", size)
+	}
+}
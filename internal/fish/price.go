@@ -0,0 +1,38 @@
+package fish
+
+import "math"
+
+// BasePrice is the market value of a median-sized fish of the given tier.
+func BasePrice(t RarityTier) int64 {
+	switch t {
+	case TierMythic:
+		return 600
+	case TierLegendary:
+		return 200
+	case TierEpic:
+		return 75
+	case TierRare:
+		return 30
+	case TierUncommon:
+		return 12
+	default:
+		return 5
+	}
+}
+
+// Price values a catch from its tier and size percentile. The multiplier
+// runs from 0.5x for the smallest possible fish to 2x for the largest, so
+// an enormous common never outsells a tiny rare by much.
+func Price(t RarityTier, percentile float64) int64 {
+	if percentile < 0 {
+		percentile = 0
+	} else if percentile > 1 {
+		percentile = 1
+	}
+	mult := 0.5 + 1.5*percentile
+	p := int64(math.Round(float64(BasePrice(t)) * mult))
+	if p < 1 {
+		p = 1
+	}
+	return p
+}
@@ -0,0 +1,155 @@
+package fish
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"time"
+)
+
+// Quest pools and how many quests each user gets from them per period
+const (
+	PoolDaily  = "daily"
+	PoolWeekly = "weekly"
+
+	DailyQuests  = 3
+	WeeklyQuests = 1
+)
+
+// Quest events
+const (
+	QuestCatch = "catch"
+	QuestSell  = "sell"
+)
+
+// Quest is a short-lived goal rolled from a pool. Catch quests count catches
+// matching the filter; sell quests count matching fish sold, or the coins
+// earned selling them if Coins is set instead of Count.
+type Quest struct {
+	Key         string `json:"key"`
+	Pool        string `json:"pool"`
+	Event       string `json:"event"`
+	Description string `json:"description"`
+	Count       int64  `json:"count"`
+	Coins       int64  `json:"coins"`
+	Reward      int64  `json:"reward"`
+
+	CatchFilter
+}
+
+// Target is the progress needed to complete the quest
+func (q Quest) Target() int64 {
+	if q.Coins > 0 {
+		return q.Coins
+	}
+	return q.Count
+}
+
+// Advance is how much progress a catch adds for the given event. price is
+// only used by coin-counting sell quests.
+func (q Quest) Advance(p *Picker, event string, c Catch, price int64) int64 {
+	if q.Event != event || !q.Matches(p, c) {
+		return 0
+	}
+	if q.Coins > 0 {
+		return price
+	}
+	return 1
+}
+
+type QuestCatalog struct {
+	items []Quest
+	byKey map[string]int
+}
+
+func LoadQuestsFromJSON(path string, reg *Registry) (*QuestCatalog, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Quest
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]int, len(items))
+	pools := map[string]int{}
+	for i := range items {
+		q := &items[i]
+		if q.Key == "" {
+			return nil, fmt.Errorf("missing key at index %d", i)
+		}
+		if _, dup := byKey[q.Key]; dup {
+			return nil, fmt.Errorf("duplicate quest key %q", q.Key)
+		}
+		switch q.Pool {
+		case PoolDaily, PoolWeekly:
+		default:
+			return nil, fmt.Errorf("quest %q has unknown pool %q", q.Key, q.Pool)
+		}
+		switch {
+		case q.Event != QuestCatch && q.Event != QuestSell:
+			return nil, fmt.Errorf("quest %q has unknown event %q", q.Key, q.Event)
+		case q.Coins > 0 && q.Event != QuestSell:
+			return nil, fmt.Errorf("quest %q counts coins but isn't a sell quest", q.Key)
+		case q.Coins > 0 && q.Count > 0:
+			return nil, fmt.Errorf("quest %q sets both count and coins", q.Key)
+		case q.Coins <= 0 && q.Count <= 0:
+			q.Count = 1
+		}
+		if q.Reward < 0 {
+			return nil, fmt.Errorf("quest %q has a negative reward", q.Key)
+		}
+		if err := q.CatchFilter.compile(reg); err != nil {
+			return nil, fmt.Errorf("quest %q: %w", q.Key, err)
+		}
+		byKey[q.Key] = i
+		pools[q.Pool]++
+	}
+	if pools[PoolDaily] < DailyQuests || pools[PoolWeekly] < WeeklyQuests {
+		return nil, fmt.Errorf("need at least %d daily and %d weekly quests, have %d and %d",
+			DailyQuests, WeeklyQuests, pools[PoolDaily], pools[PoolWeekly])
+	}
+
+	return &QuestCatalog{items: items, byKey: byKey}, nil
+}
+
+func (c *QuestCatalog) Get(key string) (Quest, bool) {
+	i, ok := c.byKey[key]
+	if !ok {
+		return Quest{}, false
+	}
+	return c.items[i], true
+}
+
+// Roll picks n different quests from a pool. The same seed always gives the
+// same quests, so rolling twice for a user and period can't disagree.
+func (c *QuestCatalog) Roll(pool string, n int, seed uint64) []Quest {
+	var candidates []Quest
+	for _, q := range c.items {
+		if q.Pool == pool {
+			candidates = append(candidates, q)
+		}
+	}
+	rng := rand.New(rand.NewPCG(seed, seed>>32|1))
+	rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	return candidates[:min(n, len(candidates))]
+}
+
+// QuestPeriod identifies the day or week now falls in, in now's location.
+// Weeks start on Monday. Returns the period key and when it ends.
+func QuestPeriod(pool string, now time.Time) (string, time.Time) {
+	day := calendarDay(now)
+	y, m, d := now.Date()
+	if pool == PoolWeekly {
+		// Day 0 of the unix epoch was a Thursday
+		week := (day + 3) / 7
+		offset := 7 - int((day+3)%7)
+		return fmt.Sprintf("w%d", week), time.Date(y, m, d+offset, 0, 0, 0, 0, now.Location())
+	}
+	return fmt.Sprintf("d%d", day), time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
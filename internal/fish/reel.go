@@ -0,0 +1,37 @@
+package fish
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Bites come between BiteDelayMin and BiteDelayMax after casting
+const (
+	BiteDelayMin = 2 * time.Second
+	BiteDelayMax = 8 * time.Second
+)
+
+// BiteDelay is how long a reeling cast waits for a bite
+func BiteDelay() time.Duration {
+	return BiteDelayMin + rand.N(BiteDelayMax-BiteDelayMin)
+}
+
+// ReelWindow is how long the angler has to press Reel once a fish bites.
+// Rarer fish are quicker to spit the hook. The windows are generous because
+// they include the round trip to Discord and back.
+func ReelWindow(t RarityTier) time.Duration {
+	switch t {
+	case TierMythic:
+		return 2500 * time.Millisecond
+	case TierLegendary:
+		return 3 * time.Second
+	case TierEpic:
+		return 3500 * time.Millisecond
+	case TierRare:
+		return 4 * time.Second
+	case TierUncommon:
+		return 5 * time.Second
+	default:
+		return 6 * time.Second
+	}
+}
@@ -4,8 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-
-	"github.com/bwmarrin/discordgo"
+	"slices"
 )
 
 type SpeciesId int
@@ -133,13 +132,6 @@ func (r *Registry) IdByKey(key string) (SpeciesId, bool) {
 	return id, ok
 }
 
-func (r *Registry) EmbedThumb(id SpeciesId) *discordgo.MessageEmbedThumbnail {
-	if sp, ok := r.GetById(id); ok && sp.Image != "" {
-		return &discordgo.MessageEmbedThumbnail{URL: sp.Image}
-	}
-	return nil
-}
-
 func (r *Registry) All() []Species {
 	out := make([]Species, len(r.byId))
 	copy(out, r.byId)
@@ -147,3 +139,7 @@ func (r *Registry) All() []Species {
 }
 
 func (r *Registry) Count() int { return len(r.byId) }
+
+func (sp Species) HasTag(tag string) bool {
+	return slices.Contains(sp.Tags, tag)
+}
@@ -0,0 +1,98 @@
+package fish
+
+import (
+	"slices"
+	"time"
+)
+
+// Streaks count calendar days with at least one catch. They're always
+// derived from catch times, so nothing needs to be kept in sync.
+
+// StreakMilestones are the streak lengths worth celebrating
+var StreakMilestones = []int{3, 7, 14, 30, 50, 100, 365}
+
+const (
+	DailyBaseReward    = 20 // coins for a /daily claim with no streak
+	DailyStreakBonus   = 10 // extra coins per streak day
+	DailyMaxBonusDays  = 14 // streak days past this don't add more coins
+	DailyMilestonePack = 1  // bait packs added on milestone days
+)
+
+func IsStreakMilestone(days int) bool {
+	return slices.Contains(StreakMilestones, days)
+}
+
+// DailyReward is the coins paid by /daily for a streak of the given length
+func DailyReward(streak int) int64 {
+	return DailyBaseReward + DailyStreakBonus*int64(min(max(streak, 0), DailyMaxBonusDays))
+}
+
+// Streaks are measured from active: times with a catch. It only needs one
+// time per stretch with catches, e.g. the start of every 15 minute slot with
+// one, rather than every catch.
+
+// LongestStreak is the longest run of consecutive days in loc with a catch
+func LongestStreak(active []time.Time, loc *time.Location) int {
+	days := catchDays(active, loc)
+
+	longest := 0
+	for d := range days {
+		if days[d-1] {
+			continue // not the start of a run
+		}
+		n := 1
+		for days[d+int64(n)] {
+			n++
+		}
+		longest = max(longest, n)
+	}
+	return longest
+}
+
+// CurrentStreak is the run of consecutive days with a catch ending today,
+// in now's location. A streak isn't broken until a whole day is missed, so
+// if there's no catch yet today the run ending yesterday still counts.
+func CurrentStreak(active []time.Time, now time.Time) int {
+	days := catchDays(active, now.Location())
+
+	d := calendarDay(now)
+	if !days[d] {
+		d--
+	}
+	n := 0
+	for days[d-int64(n)] {
+		n++
+	}
+	return n
+}
+
+// FishedToday reports whether any of active falls on now's calendar day, in
+// now's location
+func FishedToday(active []time.Time, now time.Time) bool {
+	return catchDays(active, now.Location())[calendarDay(now)]
+}
+
+func catchDays(active []time.Time, loc *time.Location) map[int64]bool {
+	days := make(map[int64]bool, len(active))
+	for _, t := range active {
+		days[calendarDay(t.In(loc))] = true
+	}
+	return days
+}
+
+// StartOfDay is midnight at the start of now's calendar day, in its location
+func StartOfDay(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+}
+
+// DayNumber numbers the calendar day of t in loc, one apart per day
+func DayNumber(t time.Time, loc *time.Location) int64 {
+	return calendarDay(t.In(loc))
+}
+
+// calendarDay numbers t's date in its own location
+func calendarDay(t time.Time) int64 {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
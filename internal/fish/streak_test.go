@@ -0,0 +1,35 @@
+package fish
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFishedToday(t *testing.T) {
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	now := time.Date(2024, time.June, 21, 9, 0, 0, 0, loc)
+	yesterday := now.Add(-24 * time.Hour)
+
+	// A three-day run ending yesterday is still the current streak today,
+	// but it doesn't include today until they fish again
+	active := []time.Time{yesterday.Add(-48 * time.Hour), yesterday.Add(-24 * time.Hour), yesterday}
+	if got := CurrentStreak(active, now); got != 3 {
+		t.Fatalf("CurrentStreak = %d, want 3", got)
+	}
+	if FishedToday(active, now) {
+		t.Error("FishedToday with no catch today")
+	}
+
+	active = append(active, now.Add(-time.Hour))
+	if got := CurrentStreak(active, now); got != 4 {
+		t.Fatalf("CurrentStreak = %d, want 4", got)
+	}
+	if !FishedToday(active, now) {
+		t.Error("FishedToday missed today's catch")
+	}
+
+	// 03:00 UTC on the 21st is still the 20th five hours west
+	if FishedToday([]time.Time{time.Date(2024, time.June, 21, 3, 0, 0, 0, time.UTC)}, now) {
+		t.Error("FishedToday used UTC days")
+	}
+}
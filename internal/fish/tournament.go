@@ -0,0 +1,144 @@
+package fish
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Tournament scoring metrics
+const (
+	MetricLargest    = "largest"    // biggest single catch in cm
+	MetricMost       = "most"       // number of catches
+	MetricPercentile = "percentile" // sum of size percentiles, so any species can compete
+)
+
+// TournamentPrizeSplit is how a prize pool is shared between the top
+// finishers, in percent
+var TournamentPrizeSplit = []int64{50, 30, 20}
+
+// TournamentTarget is which catches count towards a tournament. A negative
+// Species and empty Tag means any fish.
+type TournamentTarget struct {
+	Species SpeciesId
+	Tag     string
+}
+
+func (t TournamentTarget) Matches(reg *Registry, c Catch) bool {
+	if t.Species >= 0 && c.SpeciesId != t.Species {
+		return false
+	}
+	if t.Tag != "" {
+		sp, ok := reg.GetById(c.SpeciesId)
+		return ok && sp.HasTag(t.Tag)
+	}
+	return true
+}
+
+// Describe names the target for embeds, e.g. "Pike" or "any river fish"
+func (t TournamentTarget) Describe(reg *Registry) string {
+	switch {
+	case t.Species >= 0:
+		return reg.NameById(t.Species)
+	case t.Tag != "":
+		return fmt.Sprintf("any %s fish", t.Tag)
+	default:
+		return "any fish"
+	}
+}
+
+// Standing is one entrant's place in a tournament
+type Standing struct {
+	UserId  int64
+	Score   float64
+	Catches int
+	Best    Catch     // largest counted catch by percentile
+	At      time.Time // when the score was reached; earlier wins ties
+}
+
+// RankTournament scores every entrant's counted catches and orders them best
+// first. Entrants without a counted catch are left out.
+func RankTournament(reg *Registry, target TournamentTarget, metric string, catches []Catch) []Standing {
+	slices.SortStableFunc(catches, func(a, b Catch) int { return a.CaughtAt.Compare(b.CaughtAt) })
+
+	byUser := map[int64]*Standing{}
+	bestPct := map[int64]float64{}
+	var order []int64
+	for _, c := range catches {
+		if !target.Matches(reg, c) {
+			continue
+		}
+		sp, ok := reg.GetById(c.SpeciesId)
+		if !ok {
+			continue
+		}
+		st, ok := byUser[c.UserId]
+		if !ok {
+			st = &Standing{UserId: c.UserId}
+			byUser[c.UserId] = st
+			order = append(order, c.UserId)
+		}
+		st.Catches++
+
+		pct := SizePercentile(sp, c.Size)
+		if st.Catches == 1 || pct > bestPct[c.UserId] {
+			st.Best, bestPct[c.UserId] = c, pct
+		}
+
+		switch metric {
+		case MetricLargest:
+			if c.Size > st.Score {
+				st.Score, st.At = c.Size, c.CaughtAt
+			}
+		case MetricMost:
+			st.Score, st.At = float64(st.Catches), c.CaughtAt
+		case MetricPercentile:
+			st.Score, st.At = st.Score+pct*100, c.CaughtAt
+		}
+	}
+
+	out := make([]Standing, 0, len(order))
+	for _, userId := range order {
+		out = append(out, *byUser[userId])
+	}
+	slices.SortStableFunc(out, func(a, b Standing) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return a.At.Compare(b.At)
+	})
+	return out
+}
+
+// FormatScore renders a score in the metric's unit
+func FormatScore(metric string, score float64) string {
+	switch metric {
+	case MetricLargest:
+		return fmt.Sprintf("%.1f cm", score)
+	case MetricMost:
+		return fmt.Sprintf("%.0f catches", score)
+	default:
+		return fmt.Sprintf("%.1f pts", score)
+	}
+}
+
+// TournamentPrizes splits a prize pool over the top finishers. Shares for
+// places nobody finished in go unpaid, and rounding leftovers go to first.
+func TournamentPrizes(pool int64, finishers int) []int64 {
+	n := min(finishers, len(TournamentPrizeSplit))
+	if pool <= 0 || n == 0 {
+		return nil
+	}
+	prizes := make([]int64, n)
+	var paid, share int64
+	for idx := range n {
+		prizes[idx] = pool * TournamentPrizeSplit[idx] / 100
+		paid += prizes[idx]
+		share += TournamentPrizeSplit[idx]
+	}
+	prizes[0] += pool*share/100 - paid
+	return prizes
+}
@@ -0,0 +1,158 @@
+package fish
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/ratelimit"
+)
+
+// Weather is the condition over a guild's water for one period
+type Weather int
+
+const (
+	WeatherSunny Weather = iota
+	WeatherRain
+	WeatherStorm
+	WeatherFog
+)
+
+// WeatherPeriod is how long each condition lasts. Periods are aligned to
+// UTC midnight so every shard agrees on where they start.
+const (
+	WeatherPeriod        = 6 * time.Hour
+	weatherPeriodsPerDay = int(24 * time.Hour / WeatherPeriod)
+)
+
+func (w Weather) String() string {
+	switch w {
+	case WeatherRain:
+		return "Rain"
+	case WeatherStorm:
+		return "Storm"
+	case WeatherFog:
+		return "Fog"
+	default:
+		return "Sunny"
+	}
+}
+
+func (w Weather) Emoji() string {
+	switch w {
+	case WeatherRain:
+		return "🌧️"
+	case WeatherStorm:
+		return "⛈️"
+	case WeatherFog:
+		return "🌫️"
+	default:
+		return "☀️"
+	}
+}
+
+// weatherBoosts are the tag boosts each condition adds to a cast
+var weatherBoosts = map[Weather]map[string]float64{
+	WeatherSunny: {"reef": 0.3, "lake": 0.2},
+	WeatherRain:  {"river": 0.4, "freshwater": 0.15},
+	WeatherStorm: {"deep_sea": 0.75, "shark": 0.5, "predator": 0.25},
+	WeatherFog:   {"bottom": 0.4, "flatfish": 0.3},
+}
+
+// Boosts returns the tag boosts for the condition. The map is shared; pass
+// it to Modifiers.WithTagBoosts rather than changing it.
+func (w Weather) Boosts() map[string]float64 {
+	return weatherBoosts[w]
+}
+
+// weatherStart is the chance of each condition for the first period of a
+// day, and weatherNext the chance of moving from one condition (row) to
+// another (column) between periods. Weather tends to stick around, and
+// within a day a storm only breaks after rain, though a day can start stormy.
+var (
+	weatherStart = [4]float64{0.5, 0.25, 0.05, 0.2}
+	weatherNext  = [4][4]float64{
+		WeatherSunny: {0.7, 0.15, 0, 0.15},
+		WeatherRain:  {0.25, 0.45, 0.25, 0.05},
+		WeatherStorm: {0.1, 0.5, 0.4, 0},
+		WeatherFog:   {0.5, 0.2, 0, 0.3},
+	}
+)
+
+// Forecast is one weather period
+type Forecast struct {
+	Weather Weather
+	Start   time.Time
+	End     time.Time
+}
+
+// WeatherSim works out each guild's weather. Nothing is stored: a day's
+// conditions come from an RNG seeded with the guild and the UTC day, so
+// they're the same wherever and whenever they're computed.
+type WeatherSim struct {
+	clk ratelimit.Clock
+}
+
+// NewWeatherSim creates a simulation reading the time from clk, or the real
+// time if clk is nil
+func NewWeatherSim(clk ratelimit.Clock) *WeatherSim {
+	if clk == nil {
+		clk = ratelimit.RealClock{}
+	}
+	return &WeatherSim{clk: clk}
+}
+
+// Current is the guild's weather right now
+func (w *WeatherSim) Current(guildId int64) Forecast {
+	return w.Forecast(guildId, 1)[0]
+}
+
+// Forecast returns the current period and the n-1 after it
+func (w *WeatherSim) Forecast(guildId int64, n int) []Forecast {
+	now := w.clk.Now().UTC()
+	day := now.Truncate(24 * time.Hour)
+	period := int(now.Sub(day) / WeatherPeriod)
+
+	out := make([]Forecast, 0, n)
+	for len(out) < n {
+		for idx, cond := range weatherForDay(guildId, day) {
+			if idx < period || len(out) == n {
+				continue
+			}
+			start := day.Add(time.Duration(idx) * WeatherPeriod)
+			out = append(out, Forecast{Weather: cond, Start: start, End: start.Add(WeatherPeriod)})
+		}
+		day, period = day.AddDate(0, 0, 1), 0
+	}
+	return out
+}
+
+// weatherForDay rolls every period of a UTC day for the guild
+func weatherForDay(guildId int64, day time.Time) []Weather {
+	h := fnv.New64a()
+	var buf [16]byte
+	for i := range 8 {
+		buf[i] = byte(guildId >> (8 * i))
+		buf[8+i] = byte(day.Unix() >> (8 * i))
+	}
+	h.Write(buf[:])
+	rng := rand.New(rand.NewPCG(h.Sum64(), 0x5eed))
+
+	out := make([]Weather, weatherPeriodsPerDay)
+	out[0] = rollWeather(rng, weatherStart)
+	for i := 1; i < len(out); i++ {
+		out[i] = rollWeather(rng, weatherNext[out[i-1]])
+	}
+	return out
+}
+
+func rollWeather(rng *rand.Rand, chances [4]float64) Weather {
+	r := rng.Float64()
+	for w, c := range chances {
+		if r < c {
+			return Weather(w)
+		}
+		r -= c
+	}
+	return WeatherSunny
+}
@@ -0,0 +1,47 @@
+package fish
+
+import (
+	"testing"
+	"time"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestForecastPeriods(t *testing.T) {
+	now := time.Date(2024, time.June, 21, 13, 30, 0, 0, time.UTC)
+	sim := NewWeatherSim(fixedClock(now))
+
+	periods := sim.Forecast(42, 6)
+	if len(periods) != 6 {
+		t.Fatalf("got %d periods, want 6", len(periods))
+	}
+	// The current period started at 12:00 and the rest follow back to back,
+	// across midnight
+	start := time.Date(2024, time.June, 21, 12, 0, 0, 0, time.UTC)
+	for idx, p := range periods {
+		want := start.Add(time.Duration(idx) * WeatherPeriod)
+		if !p.Start.Equal(want) || !p.End.Equal(want.Add(WeatherPeriod)) {
+			t.Errorf("period %d runs %v-%v, want it to start at %v", idx, p.Start, p.End, want)
+		}
+	}
+	if sim.Current(42) != periods[0] {
+		t.Errorf("Current = %v, want the forecast's first period %v", sim.Current(42), periods[0])
+	}
+}
+
+func TestStormsBreakAfterRain(t *testing.T) {
+	day := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for d := range 365 {
+		for guild := range int64(20) {
+			days := weatherForDay(guild, day.AddDate(0, 0, d))
+			for idx := 1; idx < len(days); idx++ {
+				prev := days[idx-1]
+				if days[idx] == WeatherStorm && prev != WeatherRain && prev != WeatherStorm {
+					t.Fatalf("guild %d day %d: storm after %v", guild, d, prev)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Backend persists cooldown expiries so they survive a restart. The Limiter
+// keeps its own in-memory map as the source of truth and writes through to
+// the backend on every change.
+type Backend interface {
+	// Load returns every entry that is still active at now. Expired entries
+	// are skipped (and may be pruned by the implementation).
+	Load(now time.Time) (map[string]time.Time, error)
+	Save(key string, until time.Time) error
+	Delete(key string) error
+}
+
+// MemoryBackend keeps entries in a map. It does not survive a restart, but is
+// useful for tests and as an explicit "no persistence" choice.
+type MemoryBackend struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{entries: make(map[string]time.Time)}
+}
+
+func (b *MemoryBackend) Load(now time.Time) (map[string]time.Time, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := make(map[string]time.Time, len(b.entries))
+	for k, until := range b.entries {
+		if !now.Before(until) {
+			delete(b.entries, k)
+			continue
+		}
+		out[k] = until
+	}
+	return out, nil
+}
+
+func (b *MemoryBackend) Save(key string, until time.Time) error {
+	b.mu.Lock()
+	b.entries[key] = until
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *MemoryBackend) Delete(key string) error {
+	b.mu.Lock()
+	delete(b.entries, key)
+	b.mu.Unlock()
+	return nil
+}
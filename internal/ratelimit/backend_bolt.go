@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltBackend stores cooldowns in a bbolt bucket named after the limiter.
+// Values are the expiry as big-endian unix nanoseconds.
+type BoltBackend struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+func NewBoltBackend(db *bolt.DB, limiter string) (*BoltBackend, error) {
+	if db == nil {
+		return nil, errors.New("bolt backend: nil db")
+	}
+
+	bucket := []byte("cooldowns:" + limiter)
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltBackend{db: db, bucket: bucket}, nil
+}
+
+func (b *BoltBackend) Load(now time.Time) (map[string]time.Time, error) {
+	out := make(map[string]time.Time)
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(b.bucket)
+
+		var expired [][]byte
+		err := bk.ForEach(func(k, v []byte) error {
+			if len(v) != 8 {
+				expired = append(expired, append([]byte(nil), k...))
+				return nil
+			}
+			until := time.Unix(0, int64(binary.BigEndian.Uint64(v)))
+			if !now.Before(until) {
+				expired = append(expired, append([]byte(nil), k...))
+				return nil
+			}
+			out[string(k)] = until
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Deleting while iterating with ForEach is not allowed, so prune after
+		for _, k := range expired {
+			if err := bk.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (b *BoltBackend) Save(key string, until time.Time) error {
+	var v [8]byte
+	binary.BigEndian.PutUint64(v[:], uint64(until.UnixNano()))
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.bucket).Put([]byte(key), v[:])
+	})
+}
+
+func (b *BoltBackend) Delete(key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.bucket).Delete([]byte(key))
+	})
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// SQLiteBackend stores cooldowns in a `cooldowns` table. Several limiters can
+// share one database; each backend is scoped to its own limiter name.
+type SQLiteBackend struct {
+	db      *sql.DB
+	limiter string
+}
+
+func NewSQLiteBackend(db *sql.DB, limiter string) (*SQLiteBackend, error) {
+	if db == nil {
+		return nil, errors.New("sqlite backend: nil db")
+	}
+
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS cooldowns (
+			limiter     TEXT    NOT NULL,
+			key         TEXT    NOT NULL,
+			until_nanos INTEGER NOT NULL,
+			PRIMARY KEY (limiter, key)
+		);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLiteBackend{db: db, limiter: limiter}, nil
+}
+
+func (b *SQLiteBackend) Load(now time.Time) (map[string]time.Time, error) {
+	// Prune anything that has already expired, then load the remainder
+	if _, err := b.db.Exec(
+		`DELETE FROM cooldowns WHERE limiter = ? AND until_nanos <= ?`,
+		b.limiter, now.UnixNano(),
+	); err != nil {
+		return nil, err
+	}
+
+	rows, err := b.db.Query(
+		`SELECT key, until_nanos FROM cooldowns WHERE limiter = ?`,
+		b.limiter,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			key   string
+			nanos int64
+		)
+		if err := rows.Scan(&key, &nanos); err != nil {
+			return nil, err
+		}
+		out[key] = time.Unix(0, nanos)
+	}
+	return out, rows.Err()
+}
+
+func (b *SQLiteBackend) Save(key string, until time.Time) error {
+	_, err := b.db.Exec(`
+		INSERT INTO cooldowns (limiter, key, until_nanos) VALUES (?,?,?)
+		ON CONFLICT (limiter, key) DO UPDATE SET until_nanos = excluded.until_nanos
+	`, b.limiter, key, until.UnixNano())
+	return err
+}
+
+func (b *SQLiteBackend) Delete(key string) error {
+	_, err := b.db.Exec(`DELETE FROM cooldowns WHERE limiter = ? AND key = ?`, b.limiter, key)
+	return err
+}
@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"time"
+)
+
+// TokenBucket stores up to capacity charges per key. Spending a charge starts
+// a jittered recharge; charges recharge one at a time, so with 3 charges and a
+// 5 minute cooldown an empty bucket is full again after ~15 minutes.
+//
+// Internally each key keeps the deadlines of its pending recharges. Only the
+// last deadline (when the bucket is full again) is written to the Backend and
+// the sweeper heap, so the shared core treats it like any other cooldown.
+type TokenBucket struct {
+	*core
+	capacity int
+	pending  map[string][]time.Time
+}
+
+func NewTokenBucket(capacity int, min, max time.Duration, clk Clock) *TokenBucket {
+	if capacity < 1 {
+		capacity = 1
+	}
+	b := &TokenBucket{
+		core:     newCore(min, max, clk),
+		capacity: capacity,
+		pending:  make(map[string][]time.Time),
+	}
+	b.onEvict = func(key string) { delete(b.pending, key) }
+	return b
+}
+
+// NewTokenBucketWithBackend creates a token bucket that persists when each
+// key will be full again, and restores unexpired keys from b.
+func NewTokenBucketWithBackend(capacity int, min, max time.Duration, clk Clock, b Backend) (*TokenBucket, error) {
+	tb := NewTokenBucket(capacity, min, max, clk)
+	if err := tb.restore(b); err != nil {
+		tb.Stop()
+		return nil, err
+	}
+	return tb, nil
+}
+
+func (b *TokenBucket) Try(guildId, userId string) (bool, time.Duration) {
+	return b.tryKey(guildId, userKey(guildId, userId), 1)
+}
+
+// TryScaled is Try with the spent charge's recharge time multiplied by scale.
+func (b *TokenBucket) TryScaled(guildId, userId string, scale float64) (bool, time.Duration) {
+	return b.tryKey(guildId, userKey(guildId, userId), scale)
+}
+
+func (b *TokenBucket) TryGuild(guildId, bucket string) (bool, time.Duration) {
+	return b.tryKey(guildId, guildKey(guildId, bucket), 1)
+}
+
+func (b *TokenBucket) tryKey(guildId, key string, scale float64) (bool, time.Duration) {
+	now := b.clk.Now()
+
+	// Resolve outside the lock: resolvers may hit the database
+	min, max := b.bounds(guildId)
+
+	b.mu.Lock()
+	p := b.pendingFor(key, now, min, max)
+	if len(p) >= b.capacity {
+		b.mu.Unlock()
+		return false, p[0].Sub(now)
+	}
+
+	// Recharges queue behind each other rather than running in parallel
+	start := now
+	if len(p) > 0 {
+		start = p[len(p)-1]
+	}
+	full := start.Add(scaled(b.nextCooldown(min, max), scale))
+	b.pending[key] = append(p, full)
+	w := b.set(key, full)
+	b.mu.Unlock()
+
+	b.persist(w)
+	return true, 0
+}
+
+func (b *TokenBucket) Remaining(guildId, userId string) (charges, capacity int) {
+	now := b.clk.Now()
+	min, max := b.bounds(guildId)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	p := b.pendingFor(userKey(guildId, userId), now, min, max)
+	return b.capacity - len(p), b.capacity
+}
+
+// Peek returns when the user's next charge comes back, if any are recharging.
+func (b *TokenBucket) Peek(guildId, userId string) (time.Time, bool) {
+	return b.peekKey(guildId, userKey(guildId, userId))
+}
+
+func (b *TokenBucket) PeekGuild(guildId, bucket string) (time.Time, bool) {
+	return b.peekKey(guildId, guildKey(guildId, bucket))
+}
+
+func (b *TokenBucket) peekKey(guildId, key string) (time.Time, bool) {
+	now := b.clk.Now()
+	min, max := b.bounds(guildId)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	p := b.pendingFor(key, now, min, max)
+	if len(p) == 0 {
+		return time.Time{}, false
+	}
+	return p[0], true
+}
+
+func (b *TokenBucket) Reset(guildId, userId string) {
+	key := userKey(guildId, userId)
+	b.mu.Lock()
+	delete(b.next, key)
+	delete(b.pending, key)
+	w := b.delete(key)
+	b.mu.Unlock()
+
+	b.persist(w)
+}
+
+// pendingFor returns key's outstanding recharge deadlines, dropping any that
+// have passed. Keys restored from a Backend only know when they'll be full,
+// so their deadlines are rebuilt assuming evenly spaced (mean) recharges.
+// Callers must hold mu.
+func (b *TokenBucket) pendingFor(key string, now time.Time, min, max time.Duration) []time.Time {
+	p, ok := b.pending[key]
+	if !ok {
+		full, ok := b.next[key]
+		if !ok || !now.Before(full) {
+			return nil
+		}
+		p = rebuildPending(full, now, (min+max)/2, b.capacity)
+	}
+
+	i := 0
+	for i < len(p) && !now.Before(p[i]) {
+		i++
+	}
+	p = p[i:]
+	if len(p) == 0 {
+		delete(b.pending, key)
+		return nil
+	}
+	b.pending[key] = p
+	return p
+}
+
+func rebuildPending(full, now time.Time, step time.Duration, capacity int) []time.Time {
+	if step <= 0 {
+		return []time.Time{full}
+	}
+	n := int((full.Sub(now) + step - 1) / step)
+	if n > capacity {
+		n = capacity
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	p := make([]time.Time, n)
+	for i := range p {
+		p[i] = full.Add(-time.Duration(n-1-i) * step)
+	}
+	return p
+}
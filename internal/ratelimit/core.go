@@ -0,0 +1,240 @@
+package ratelimit
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"log"
+	mrand "math/rand"
+	"sync"
+	"time"
+)
+
+// Resolver supplies per-guild cooldown bounds. Returning ok=false makes the
+// limiter fall back to its own min/max.
+type Resolver interface {
+	Cooldown(guildId string) (min, max time.Duration, ok bool)
+}
+
+// ResolverFunc adapts a plain function to the Resolver interface.
+type ResolverFunc func(guildId string) (min, max time.Duration, ok bool)
+
+func (f ResolverFunc) Cooldown(guildId string) (time.Duration, time.Duration, bool) {
+	return f(guildId)
+}
+
+// core is the bookkeeping shared by every limiter type: the key -> expiry
+// map that the sweeper and Backend operate on, jittered cooldowns, and
+// per-guild bounds. An entry in next means the key still has state; once it
+// expires the key is indistinguishable from one that never acted.
+type core struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+	min  time.Duration
+	max  time.Duration
+	clk  Clock
+	rng  *mrand.Rand
+	back Backend  // optional; nil means cooldowns are in-memory only
+	res  Resolver // optional; nil means every guild uses min/max
+
+	// onEvict lets a limiter drop any extra per-key state when the sweeper
+	// removes a key. Called with mu held.
+	onEvict func(key string)
+
+	// Backend writes happen outside mu, so two writes for the same key can
+	// reach persist in either order. Each write takes a sequence number under
+	// mu, and persist drops any write older than the last one applied for its
+	// key. inflight (guarded by mu) counts each key's unfinished writes, so
+	// applied (guarded by pmu) only holds keys with writes in flight.
+	seq      uint64
+	inflight map[string]int
+	pmu      sync.Mutex
+	applied  map[string]uint64
+
+	expiries  expiryHeap
+	evictions uint64
+	stop      chan struct{}
+	done      chan struct{}
+	stopOnce  sync.Once
+}
+
+func newCore(min, max time.Duration, clk Clock) *core {
+	if clk == nil {
+		clk = RealClock{}
+	}
+	if max < min {
+		max = min
+	}
+
+	seed := func() int64 {
+		var b [8]byte
+		if _, err := rand.Read(b[:]); err == nil {
+			return int64(binary.LittleEndian.Uint64(b[:]))
+		}
+		return time.Now().UnixNano()
+	}()
+
+	c := &core{
+		next:     make(map[string]time.Time),
+		min:      min,
+		max:      max,
+		clk:      clk,
+		rng:      mrand.New(mrand.NewSource(seed)),
+		inflight: make(map[string]int),
+		applied:  make(map[string]uint64),
+	}
+	c.startSweeper(DefaultSweepInterval)
+	return c
+}
+
+// restore loads unexpired entries from b and enables write-through.
+func (c *core) restore(b Backend) error {
+	if b == nil {
+		return nil
+	}
+
+	entries, err := b.Load(c.clk.Now())
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	for k, until := range entries {
+		c.next[k] = until
+		c.track(k, until)
+	}
+	c.back = b
+	c.mu.Unlock()
+	return nil
+}
+
+// SetResolver installs a per-guild cooldown lookup. It should be called
+// before the limiter is shared between goroutines.
+func (c *core) SetResolver(r Resolver) {
+	c.mu.Lock()
+	c.res = r
+	c.mu.Unlock()
+}
+
+// Defaults returns the bot-wide cooldown range used when no per-guild
+// override applies.
+func (c *core) Defaults() (min, max time.Duration) {
+	return c.min, c.max
+}
+
+func (c *core) bounds(guildId string) (time.Duration, time.Duration) {
+	c.mu.Lock()
+	res := c.res
+	c.mu.Unlock()
+
+	if res == nil || guildId == "" {
+		return c.min, c.max
+	}
+	min, max, ok := res.Cooldown(guildId)
+	if !ok {
+		return c.min, c.max
+	}
+	if max < min {
+		max = min
+	}
+	return min, max
+}
+
+// nextCooldown picks a jittered duration in [min, max). Callers must hold mu,
+// since rand.Rand is not safe for concurrent use.
+func (c *core) nextCooldown(min, max time.Duration) time.Duration {
+	if min == max {
+		return min
+	}
+	span := max - min
+
+	jitter := time.Duration(c.rng.Int63n(int64(span)))
+	return min + jitter
+}
+
+func scaled(d time.Duration, scale float64) time.Duration {
+	if scale <= 0 || scale == 1 {
+		return d
+	}
+	return time.Duration(float64(d) * scale)
+}
+
+// write is a Backend change collected while mu is held and applied after it
+// is released, so a slow disk never holds up other keys. A zero until
+// deletes the key.
+type write struct {
+	back  Backend
+	key   string
+	until time.Time
+	seq   uint64
+}
+
+// set records key's new expiry in memory and in the sweeper heap, and returns
+// the backend write for the caller to persist once mu is released. Callers
+// must hold mu.
+func (c *core) set(key string, until time.Time) write {
+	c.next[key] = until
+	c.track(key, until)
+	return c.queue(key, until)
+}
+
+// delete returns the backend write removing key. Callers must hold mu.
+func (c *core) delete(key string) write {
+	return c.queue(key, time.Time{})
+}
+
+// queue numbers a backend write for key. Callers must hold mu.
+func (c *core) queue(key string, until time.Time) write {
+	if c.back == nil {
+		return write{}
+	}
+	c.seq++
+	c.inflight[key]++
+	return write{back: c.back, key: key, until: until, seq: c.seq}
+}
+
+// persist applies writes collected by set and delete. It must be called
+// without mu held. Backend writes are best-effort: the in-memory map stays
+// authoritative, so a failed write only means the cooldown may not survive a
+// restart.
+func (c *core) persist(ws ...write) {
+	c.pmu.Lock()
+	defer c.pmu.Unlock()
+
+	for _, w := range ws {
+		if w.back == nil || w.seq < c.applied[w.key] {
+			// a newer write for this key already landed
+			continue
+		}
+		c.applied[w.key] = w.seq
+		if w.until.IsZero() {
+			if err := w.back.Delete(w.key); err != nil {
+				log.Printf("ratelimit: failed to delete %q: %v", w.key, err)
+			}
+			continue
+		}
+		if err := w.back.Save(w.key, w.until); err != nil {
+			log.Printf("ratelimit: failed to persist %q: %v", w.key, err)
+		}
+	}
+
+	c.mu.Lock()
+	for _, w := range ws {
+		if w.back == nil {
+			continue
+		}
+		if c.inflight[w.key]--; c.inflight[w.key] == 0 {
+			// any later write gets a higher seq than everything applied
+			delete(c.inflight, w.key)
+			delete(c.applied, w.key)
+		}
+	}
+	c.mu.Unlock()
+}
+
+func userKey(guildId, userId string) string {
+	return guildId + ":" + userId
+}
+
+func guildKey(guildId, bucket string) string {
+	return "g:" + guildId + "|b:" + bucket
+}
@@ -0,0 +1,25 @@
+package ratelimit
+
+import "time"
+
+// Gate is the behaviour the bot relies on from a limiter, so commands can be
+// backed by either a fixed cooldown (Limiter) or stored charges (TokenBucket).
+type Gate interface {
+	Try(guildId, userId string) (bool, time.Duration)
+	TryScaled(guildId, userId string, scale float64) (bool, time.Duration)
+	TryGuild(guildId, bucket string) (bool, time.Duration)
+	Remaining(guildId, userId string) (charges, capacity int)
+	Peek(guildId, userId string) (time.Time, bool)
+	PeekGuild(guildId, bucket string) (time.Time, bool)
+	Reset(guildId, userId string)
+
+	SetResolver(r Resolver)
+	Defaults() (min, max time.Duration)
+	Stats() Stats
+	Stop()
+}
+
+var (
+	_ Gate = (*Limiter)(nil)
+	_ Gate = (*TokenBucket)(nil)
+)
@@ -1,10 +1,6 @@
 package ratelimit
 
 import (
-	"crypto/rand"
-	"encoding/binary"
-	mrand "math/rand"
-	"sync"
 	"time"
 )
 
@@ -16,81 +12,104 @@ type RealClock struct{}
 
 func (RealClock) Now() time.Time { return time.Now() }
 
+// Limiter allows one action per key, then blocks it for a jittered cooldown.
 type Limiter struct {
-	mu   sync.Mutex
-	next map[string]time.Time
-	min  time.Duration
-	max  time.Duration
-	clk  Clock
-	rng  *mrand.Rand
+	*core
 }
 
 func NewLimiter(min, max time.Duration, clk Clock) *Limiter {
-	if clk == nil {
-		clk = RealClock{}
-	}
-	if max < min {
-		max = min
-	}
+	return &Limiter{core: newCore(min, max, clk)}
+}
 
-	seed := func() int64 {
-		var b [8]byte
-		if _, err := rand.Read(b[:]); err == nil {
-			return int64(binary.LittleEndian.Uint64(b[:]))
-		}
-		return time.Now().UnixNano()
-	}()
-
-	return &Limiter{
-		next: make(map[string]time.Time),
-		min:  min,
-		max:  max,
-		clk:  clk,
-		rng:  mrand.New(mrand.NewSource(seed)),
+// NewLimiterWithBackend creates a limiter that writes every cooldown through
+// to b, and restores any cooldowns from b that have not yet expired.
+func NewLimiterWithBackend(min, max time.Duration, clk Clock, b Backend) (*Limiter, error) {
+	l := NewLimiter(min, max, clk)
+	if err := l.restore(b); err != nil {
+		l.Stop()
+		return nil, err
 	}
+	return l, nil
 }
 
 func (l *Limiter) TryKey(key string) (bool, time.Duration) {
+	return l.tryKey("", key, 1)
+}
+
+func (l *Limiter) tryKey(guildId, key string, scale float64) (bool, time.Duration) {
 	now := l.clk.Now()
 
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	// Resolve outside the lock: resolvers may hit the database
+	min, max := l.bounds(guildId)
 
+	l.mu.Lock()
 	if until, ok := l.next[key]; ok && now.Before(until) {
-		return false, time.Until(until)
+		l.mu.Unlock()
+		return false, until.Sub(now)
 	}
+	w := l.set(key, now.Add(scaled(l.nextCooldown(min, max), scale)))
+	l.mu.Unlock()
 
-	l.next[key] = now.Add(l.nextCooldown())
+	l.persist(w)
 	return true, 0
 }
 
 func (l *Limiter) Try(guildId, userId string) (bool, time.Duration) {
-	return l.TryKey(guildId + ":" + userId)
+	return l.tryKey(guildId, userKey(guildId, userId), 1)
+}
+
+// TryScaled is Try with the resulting cooldown multiplied by scale, e.g. 0.9
+// for gear that shortens cooldowns by 10%.
+func (l *Limiter) TryScaled(guildId, userId string, scale float64) (bool, time.Duration) {
+	return l.tryKey(guildId, userKey(guildId, userId), scale)
 }
 
 func (l *Limiter) TryGuild(guildId, bucket string) (bool, time.Duration) {
-	return l.TryKey("g:" + guildId + "|b:" + bucket)
+	return l.tryKey(guildId, guildKey(guildId, bucket), 1)
 }
 
-func (l *Limiter) nextCooldown() time.Duration {
-	if l.min == l.max {
-		return l.min
-	}
-	span := l.max - l.min
+// Remaining reports whether the user can act right now. A fixed-cooldown
+// limiter only ever holds a single charge.
+func (l *Limiter) Remaining(guildId, userId string) (charges, capacity int) {
+	now := l.clk.Now()
 
-	jitter := time.Duration(l.rng.Int63n(int64(span)))
-	return l.min + jitter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until, ok := l.next[userKey(guildId, userId)]; ok && now.Before(until) {
+		return 0, 1
+	}
+	return 1, 1
 }
 
 func (l *Limiter) Reset(guildId, userId string) {
+	key := userKey(guildId, userId)
 	l.mu.Lock()
-	delete(l.next, guildId+":"+userId)
+	delete(l.next, key)
+	w := l.delete(key)
 	l.mu.Unlock()
+
+	l.persist(w)
 }
 
+// Peek returns when the user may act again. Expired entries that the sweeper
+// has not yet evicted are reported as absent.
 func (l *Limiter) Peek(guildId, userId string) (time.Time, bool) {
+	return l.peekKey(userKey(guildId, userId))
+}
+
+func (l *Limiter) PeekGuild(guildId, bucket string) (time.Time, bool) {
+	return l.peekKey(guildKey(guildId, bucket))
+}
+
+func (l *Limiter) peekKey(key string) (time.Time, bool) {
+	now := l.clk.Now()
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	t, ok := l.next[guildId+":"+userId]
-	return t, ok
+	t, ok := l.next[key]
+	if !ok || !now.Before(t) {
+		return time.Time{}, false
+	}
+	return t, true
 }
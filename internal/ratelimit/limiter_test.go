@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newGates(clk Clock) map[string]Gate {
+	return map[string]Gate{
+		"limiter": NewLimiter(time.Minute, time.Minute, clk),
+		"bucket":  NewTokenBucket(1, time.Minute, time.Minute, clk),
+	}
+}
+
+func TestTryThenPeek(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	for name, g := range newGates(clk) {
+		t.Run(name, func(t *testing.T) {
+			defer g.Stop()
+
+			if _, ok := g.Peek("1", "2"); ok {
+				t.Fatal("Peek reported a cooldown before Try")
+			}
+			if ok, _ := g.Try("1", "2"); !ok {
+				t.Fatal("first Try was refused")
+			}
+			until, ok := g.Peek("1", "2")
+			if !ok || !until.Equal(clk.now.Add(time.Minute)) {
+				t.Fatalf("Peek = %v, %v; want %v, true", until, ok, clk.now.Add(time.Minute))
+			}
+			if _, ok := g.Peek("1", "3"); ok {
+				t.Error("Peek reported another user's cooldown")
+			}
+		})
+	}
+}
+
+func TestTryGuildThenPeekGuild(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	for name, g := range newGates(clk) {
+		t.Run(name, func(t *testing.T) {
+			defer g.Stop()
+
+			if ok, _ := g.TryGuild("1", "leaderboard"); !ok {
+				t.Fatal("first TryGuild was refused")
+			}
+			until, ok := g.PeekGuild("1", "leaderboard")
+			if !ok || !until.Equal(clk.now.Add(time.Minute)) {
+				t.Fatalf("PeekGuild = %v, %v; want %v, true", until, ok, clk.now.Add(time.Minute))
+			}
+			if ok, rem := g.TryGuild("1", "leaderboard"); ok || rem != time.Minute {
+				t.Errorf("second TryGuild = %v, %v; want false, %v", ok, rem, time.Minute)
+			}
+			// Guild buckets and user cooldowns don't share keys
+			if _, ok := g.Peek("1", "leaderboard"); ok {
+				t.Error("Peek saw the guild bucket")
+			}
+
+			clk.now = clk.now.Add(time.Minute)
+			if _, ok := g.PeekGuild("1", "leaderboard"); ok {
+				t.Error("PeekGuild still reports an expired cooldown")
+			}
+		})
+	}
+}
+
+func TestPersistDropsStaleWrites(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	back := NewMemoryBackend()
+	l, err := NewLimiterWithBackend(time.Minute, time.Minute, clk, back)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Stop()
+
+	// Writes reach persist in the opposite order to the one they were made in
+	l.mu.Lock()
+	saved := l.set("k", clk.now.Add(time.Minute))
+	deleted := l.delete("k")
+	l.mu.Unlock()
+	l.persist(deleted)
+	l.persist(saved)
+	if entries, _ := back.Load(clk.now); len(entries) != 0 {
+		t.Errorf("backend = %v after a stale save, want it empty", entries)
+	}
+
+	l.mu.Lock()
+	first := l.set("k", clk.now.Add(time.Minute))
+	second := l.set("k", clk.now.Add(2*time.Minute))
+	l.mu.Unlock()
+	l.persist(second)
+	l.persist(first)
+	if entries, _ := back.Load(clk.now); !entries["k"].Equal(clk.now.Add(2 * time.Minute)) {
+		t.Errorf("backend = %v, want the newer save", entries)
+	}
+
+	l.mu.Lock()
+	pending := len(l.inflight)
+	l.mu.Unlock()
+	if pending != 0 || len(l.applied) != 0 {
+		t.Errorf("%d keys in flight, %d applied; want none once every write is persisted", pending, len(l.applied))
+	}
+}
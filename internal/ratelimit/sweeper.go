@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"container/heap"
+	"sync/atomic"
+	"time"
+)
+
+// How often the background sweeper evicts expired keys
+const DefaultSweepInterval = time.Minute
+
+// Stats is a point-in-time snapshot of a limiter's bookkeeping.
+type Stats struct {
+	TrackedKeys int    // keys currently held in memory
+	Evictions   uint64 // expired keys removed by the sweeper since startup
+}
+
+type expiry struct {
+	key   string
+	until time.Time
+}
+
+// expiryHeap is a min-heap ordered by expiry time. Entries are never updated
+// in place: a key that gets a new cooldown is pushed again, and stale entries
+// are skipped when popped because they no longer match the limiter's map.
+type expiryHeap []expiry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].until.Before(h[j].until) }
+func (h expiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *expiryHeap) Push(x any)        { *h = append(*h, x.(expiry)) }
+func (h *expiryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	*h = old[:n-1]
+	return e
+}
+
+// track records a new expiry for key. Callers must hold mu.
+func (c *core) track(key string, until time.Time) {
+	heap.Push(&c.expiries, expiry{key: key, until: until})
+}
+
+func (c *core) startSweeper(interval time.Duration) {
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				c.Sweep()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Sweep evicts every key whose cooldown has expired according to the
+// limiter's Clock. It runs periodically in the background, but can also be
+// called directly (e.g. from tests with a fake Clock).
+func (c *core) Sweep() int {
+	now := c.clk.Now()
+
+	c.mu.Lock()
+	var writes []write
+	evicted := 0
+	for c.expiries.Len() > 0 && !now.Before(c.expiries[0].until) {
+		e := heap.Pop(&c.expiries).(expiry)
+		if until, ok := c.next[e.key]; !ok || !until.Equal(e.until) {
+			// reset, or superseded by a newer cooldown
+			continue
+		}
+		delete(c.next, e.key)
+		if c.onEvict != nil {
+			c.onEvict(e.key)
+		}
+		writes = append(writes, c.delete(e.key))
+		evicted++
+	}
+	c.mu.Unlock()
+
+	c.persist(writes...)
+	atomic.AddUint64(&c.evictions, uint64(evicted))
+	return evicted
+}
+
+func (c *core) Stats() Stats {
+	c.mu.Lock()
+	tracked := len(c.next)
+	c.mu.Unlock()
+
+	return Stats{
+		TrackedKeys: tracked,
+		Evictions:   atomic.LoadUint64(&c.evictions),
+	}
+}
+
+// Stop halts the background sweeper. It is safe to call more than once.
+func (c *core) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+		<-c.done
+	})
+}
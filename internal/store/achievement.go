@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Achievements are defined in data (see fish.AchievementCatalog); only which
+// ones each user has earned, and their equipped title, is stored.
+const achievementSchema = `
+	CREATE TABLE IF NOT EXISTS achievements (
+		guild_id   BIGINT  NOT NULL,
+		user_id    BIGINT  NOT NULL,
+		key        TEXT    NOT NULL,
+		earned_at  INTEGER NOT NULL,
+		PRIMARY KEY (guild_id, user_id, key)
+	);
+
+	CREATE TABLE IF NOT EXISTS user_titles (
+		guild_id   BIGINT NOT NULL,
+		user_id    BIGINT NOT NULL,
+		title_key  TEXT   NOT NULL,
+		PRIMARY KEY (guild_id, user_id)
+	);
+`
+
+// Achievements returns when the user earned each of their achievements
+func (s *SQLiteStore) Achievements(ctx context.Context, guildId, userId int64) (map[string]time.Time, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT key, earned_at FROM achievements WHERE guild_id = ? AND user_id = ?`,
+		guildId, userId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			key    string
+			earned int64
+		)
+		if err := rows.Scan(&key, &earned); err != nil {
+			return nil, err
+		}
+		out[key] = time.Unix(earned, 0).UTC()
+	}
+	return out, rows.Err()
+}
+
+// AwardAchievements records the given achievements and returns the keys that
+// were newly earned. Each achievement is only ever awarded once, even if two
+// catches race to unlock it.
+func (s *SQLiteStore) AwardAchievements(ctx context.Context, guildId, userId int64, keys []string) ([]string, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	var awarded []string
+	for _, key := range keys {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO achievements (guild_id, user_id, key, earned_at) VALUES (?,?,?,?)
+			ON CONFLICT DO NOTHING
+		`, guildId, userId, key, now)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			awarded = append(awarded, key)
+		}
+	}
+	return awarded, tx.Commit()
+}
+
+// EquippedTitle returns the achievement key of the user's title, or ""
+func (s *SQLiteStore) EquippedTitle(ctx context.Context, guildId, userId int64) (string, error) {
+	titles, err := s.EquippedTitles(ctx, guildId, []int64{userId})
+	return titles[userId], err
+}
+
+// EquippedTitles looks up the titles of several users at once, e.g. for a
+// leaderboard. Users without a title are left out.
+func (s *SQLiteStore) EquippedTitles(ctx context.Context, guildId int64, userIds []int64) (map[int64]string, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	out := make(map[int64]string)
+	if len(userIds) == 0 {
+		return out, nil
+	}
+
+	args := []any{guildId}
+	for _, id := range userIds {
+		args = append(args, id)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, title_key FROM user_titles
+		WHERE guild_id = ? AND user_id IN (?`+strings.Repeat(",?", len(userIds)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			userId int64
+			key    string
+		)
+		if err := rows.Scan(&userId, &key); err != nil {
+			return nil, err
+		}
+		out[userId] = key
+	}
+	return out, rows.Err()
+}
+
+// EquipTitle sets the user's title to an earned achievement's; an empty key
+// removes it
+func (s *SQLiteStore) EquipTitle(ctx context.Context, guildId, userId int64, key string) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	if key == "" {
+		_, err := s.db.ExecContext(ctx,
+			`DELETE FROM user_titles WHERE guild_id = ? AND user_id = ?`,
+			guildId, userId,
+		)
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_titles (guild_id, user_id, title_key)
+		SELECT guild_id, user_id, key FROM achievements
+		WHERE guild_id = ? AND user_id = ? AND key = ?
+		ON CONFLICT (guild_id, user_id) DO UPDATE SET title_key = excluded.title_key
+	`, guildId, userId, key)
+	return err
+}
@@ -0,0 +1,368 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/fish"
+)
+
+// An auction holds the current top bid in escrow; being outbid refunds the
+// previous bidder in the same transaction. The catch stays with the seller
+// (but can't be sold or traded) until the auction is settled. announced
+// tracks whether the result was posted, separately from settlement, so a
+// crash between the two doesn't lose the announcement.
+const auctionSchema = `
+	CREATE TABLE IF NOT EXISTS auctions (
+		id             INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id       BIGINT  NOT NULL,
+		channel_id     BIGINT  NOT NULL,
+		seller_id      BIGINT  NOT NULL,
+		catch_id       INTEGER NOT NULL REFERENCES catches (id),
+		min_bid        INTEGER NOT NULL,
+		top_bid        INTEGER NOT NULL DEFAULT 0,
+		top_bidder_id  BIGINT  NOT NULL DEFAULT 0,
+		created_at     INTEGER NOT NULL,
+		ends_at        INTEGER NOT NULL,
+		status         TEXT    NOT NULL DEFAULT 'open',
+		settled_at     INTEGER,
+		announced      INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS idx_auctions_status ON auctions (status, ends_at);
+	CREATE INDEX IF NOT EXISTS idx_auctions_catch ON auctions (catch_id, status);
+
+	CREATE TABLE IF NOT EXISTS auction_bids (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		auction_id  INTEGER NOT NULL REFERENCES auctions (id),
+		bidder_id   BIGINT  NOT NULL,
+		amount      INTEGER NOT NULL,
+		placed_at   INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_auction_bids_auction ON auction_bids (auction_id);
+`
+
+// notAuctioned filters catches down to ones not up for auction
+const notAuctioned = `id NOT IN (SELECT catch_id FROM auctions WHERE status = 'open')`
+
+const (
+	AuctionOpen      = "open"
+	AuctionSold      = "sold"
+	AuctionUnsold    = "unsold"
+	AuctionCancelled = "cancelled"
+)
+
+var (
+	ErrAuctionClosed = errors.New("auction is no longer open")
+	ErrBidTooLow     = errors.New("bid is too low")
+	ErrOwnAuction    = errors.New("can't bid on your own auction")
+	ErrHasBids       = errors.New("auction already has bids")
+	ErrNotSeller     = errors.New("not the seller")
+	ErrNoAuction     = errors.New("auction not found")
+)
+
+type Auction struct {
+	Id          int64
+	GuildId     int64
+	ChannelId   int64
+	SellerId    int64
+	Catch       fish.Catch
+	MinBid      int64
+	TopBid      int64
+	TopBidderId int64 // 0 if there are no bids
+	EndsAt      time.Time
+	Status      string
+}
+
+// NextMinBid is the smallest bid that would currently be accepted: the
+// minimum, or 5% over the top bid
+func (a Auction) NextMinBid() int64 {
+	if a.TopBidderId == 0 {
+		return a.MinBid
+	}
+	return a.TopBid + max(1, a.TopBid/20)
+}
+
+const auctionColumns = `
+	a.id, a.guild_id, a.channel_id, a.seller_id, a.min_bid, a.top_bid, a.top_bidder_id, a.ends_at, a.status,
+	c.id, c.user_id, c.species_id, c.size_tenths, c.caught_at
+`
+
+func scanAuction(row interface{ Scan(...any) error }) (Auction, error) {
+	var (
+		a                    Auction
+		endsUnix, caughtUnix int64
+		spid                 int
+		sizeTenths           int64
+	)
+	err := row.Scan(&a.Id, &a.GuildId, &a.ChannelId, &a.SellerId, &a.MinBid, &a.TopBid, &a.TopBidderId, &endsUnix, &a.Status,
+		&a.Catch.Id, &a.Catch.UserId, &spid, &sizeTenths, &caughtUnix)
+	if err != nil {
+		return a, err
+	}
+	a.EndsAt = time.Unix(endsUnix, 0).UTC()
+	a.Catch.GuildId = a.GuildId
+	a.Catch.SpeciesId = fish.SpeciesId(spid)
+	a.Catch.Size = float64(sizeTenths) / 10.0
+	a.Catch.CaughtAt = time.Unix(caughtUnix, 0).UTC()
+	return a, nil
+}
+
+func loadAuctionTx(ctx context.Context, q querier, auctionId int64) (Auction, error) {
+	a, err := scanAuction(q.QueryRowContext(ctx, `
+		SELECT `+auctionColumns+`
+		FROM auctions a JOIN catches c ON c.id = a.catch_id
+		WHERE a.id = ?
+	`, auctionId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, ErrNoAuction
+	}
+	return a, err
+}
+
+func queryAuctions(ctx context.Context, q querier, where string, args ...any) ([]Auction, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT `+auctionColumns+`
+		FROM auctions a JOIN catches c ON c.id = a.catch_id
+		WHERE `+where+`
+		ORDER BY a.ends_at, a.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Auction
+	for rows.Next() {
+		a, err := scanAuction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// CreateAuction puts one of the seller's unsold catches up for auction.
+// Fails with ErrCatchNotOwned if it isn't theirs to sell.
+func (s *SQLiteStore) CreateAuction(ctx context.Context, guildId, channelId, sellerId, catchId, minBid int64, endsAt time.Time) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM catches
+		WHERE id = ? AND guild_id = ? AND user_id = ? AND sold_at IS NULL AND released_at IS NULL AND `+notAuctioned,
+		catchId, guildId, sellerId,
+	).Scan(&n); err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, ErrCatchNotOwned
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO auctions (guild_id, channel_id, seller_id, catch_id, min_bid, created_at, ends_at)
+		VALUES (?,?,?,?,?,?,?)
+	`, guildId, channelId, sellerId, catchId, max(minBid, 1), time.Now().Unix(), endsAt.Unix())
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// PlaceBid escrows the bid and refunds whoever held the top bid before.
+// Returns the auction as it was before the bid, so callers can tell the
+// previous top bidder they were outbid. Auctions from other guilds are
+// ErrNoAuction, so nothing about them leaks to the bidder.
+func (s *SQLiteStore) PlaceBid(ctx context.Context, guildId, auctionId, bidderId, amount int64) (Auction, error) {
+	if s == nil || s.db == nil {
+		return Auction{}, errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Auction{}, err
+	}
+	defer tx.Rollback()
+
+	a, err := loadAuctionTx(ctx, tx, auctionId)
+	if err == nil && a.GuildId != guildId {
+		err = ErrNoAuction
+	}
+	if err != nil {
+		return Auction{}, err
+	}
+	now := time.Now()
+	if a.Status != AuctionOpen || !now.Before(a.EndsAt) {
+		return a, ErrAuctionClosed
+	}
+	if bidderId == a.SellerId {
+		return a, ErrOwnAuction
+	}
+	if amount < a.NextMinBid() {
+		return a, ErrBidTooLow
+	}
+
+	// Refund first so a bidder raising their own bid only needs the difference
+	postings := []Posting{}
+	if a.TopBidderId != 0 {
+		postings = append(postings, Posting{Account: UserAccount(a.TopBidderId), Amount: a.TopBid})
+	}
+	postings = append(postings,
+		Posting{Account: UserAccount(bidderId), Amount: -amount},
+		Posting{Account: AccountEscrow, Amount: amount - a.TopBid},
+	)
+	if _, err := post(ctx, tx, a.GuildId, "auction_bid", fmt.Sprintf("auction #%d", auctionId), now, postings...); err != nil {
+		return a, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE auctions SET top_bid = ?, top_bidder_id = ? WHERE id = ?
+	`, amount, bidderId, auctionId); err != nil {
+		return a, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO auction_bids (auction_id, bidder_id, amount, placed_at) VALUES (?,?,?,?)
+	`, auctionId, bidderId, amount, now.Unix()); err != nil {
+		return a, err
+	}
+	return a, tx.Commit()
+}
+
+// CancelAuction withdraws an auction that nobody has bid on yet
+func (s *SQLiteStore) CancelAuction(ctx context.Context, auctionId, sellerId int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	a, err := loadAuctionTx(ctx, tx, auctionId)
+	if err != nil {
+		return err
+	}
+	switch {
+	case a.SellerId != sellerId:
+		return ErrNotSeller
+	case a.Status != AuctionOpen:
+		return ErrAuctionClosed
+	case a.TopBidderId != 0:
+		return ErrHasBids
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE auctions SET status = ?, settled_at = ?, announced = 1 WHERE id = ?
+	`, AuctionCancelled, time.Now().Unix(), auctionId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SettleAuction closes an auction that has ended: the catch goes to the top
+// bidder and the escrowed bid to the seller. Settling is idempotent; an
+// auction that is already settled (or hasn't ended) returns ErrAuctionClosed.
+func (s *SQLiteStore) SettleAuction(ctx context.Context, auctionId int64, now time.Time) (Auction, error) {
+	if s == nil || s.db == nil {
+		return Auction{}, errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Auction{}, err
+	}
+	defer tx.Rollback()
+
+	a, err := loadAuctionTx(ctx, tx, auctionId)
+	if err != nil {
+		return a, err
+	}
+	if a.Status != AuctionOpen || now.Before(a.EndsAt) {
+		return a, ErrAuctionClosed
+	}
+
+	a.Status = AuctionUnsold
+	if a.TopBidderId != 0 {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE catches SET user_id = ?
+			WHERE id = ? AND guild_id = ? AND user_id = ? AND sold_at IS NULL
+		`, a.TopBidderId, a.Catch.Id, a.GuildId, a.SellerId)
+		if err != nil {
+			return a, err
+		}
+
+		memo := fmt.Sprintf("auction #%d", auctionId)
+		if n, _ := res.RowsAffected(); n == 1 {
+			a.Status = AuctionSold
+			_, err = post(ctx, tx, a.GuildId, "auction_sale", memo, now,
+				Posting{Account: AccountEscrow, Amount: -a.TopBid},
+				Posting{Account: UserAccount(a.SellerId), Amount: a.TopBid},
+			)
+		} else {
+			// The catch vanished from under the auction; give the bid back
+			_, err = post(ctx, tx, a.GuildId, "auction_refund", memo, now,
+				Posting{Account: AccountEscrow, Amount: -a.TopBid},
+				Posting{Account: UserAccount(a.TopBidderId), Amount: a.TopBid},
+			)
+		}
+		if err != nil {
+			return a, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE auctions SET status = ?, settled_at = ? WHERE id = ? AND status = ?
+	`, a.Status, now.Unix(), auctionId, AuctionOpen); err != nil {
+		return a, err
+	}
+	return a, tx.Commit()
+}
+
+// OpenAuctions lists a guild's running auctions, ending soonest first
+func (s *SQLiteStore) OpenAuctions(ctx context.Context, guildId int64) ([]Auction, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return queryAuctions(ctx, s.db, `a.guild_id = ? AND a.status = ?`, guildId, AuctionOpen)
+}
+
+// DueAuctions lists open auctions in any guild that have ended by now
+func (s *SQLiteStore) DueAuctions(ctx context.Context, now time.Time) ([]Auction, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return queryAuctions(ctx, s.db, `a.status = ? AND a.ends_at <= ?`, AuctionOpen, now.Unix())
+}
+
+// UnannouncedAuctions lists settled auctions whose result hasn't been posted
+func (s *SQLiteStore) UnannouncedAuctions(ctx context.Context) ([]Auction, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return queryAuctions(ctx, s.db, `a.status IN (?, ?) AND a.announced = 0`, AuctionSold, AuctionUnsold)
+}
+
+func (s *SQLiteStore) MarkAuctionAnnounced(ctx context.Context, auctionId int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE auctions SET announced = 1 WHERE id = ?`, auctionId)
+	return err
+}
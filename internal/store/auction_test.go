@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/fish"
+)
+
+func TestPlaceBidOtherGuild(t *testing.T) {
+	st := openTestStore(t)
+	ctx := context.Background()
+
+	const guild, seller, bidder = 1, 2, 3
+	c := fish.Catch{GuildId: guild, UserId: seller, SpeciesId: 3, Size: 42, CaughtAt: time.Unix(1_700_000_000, 0)}
+	catchId, err := st.Add(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auctionId, err := st.CreateAuction(ctx, guild, 10, seller, catchId, 50, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// None of these may tell another guild anything about the auction
+	bids := []struct {
+		name           string
+		bidder, amount int64
+	}{
+		{"too low", bidder, 1},
+		{"own auction", seller, 100},
+		{"unfunded", bidder, 100},
+	}
+	for _, tc := range bids {
+		a, err := st.PlaceBid(ctx, guild+1, auctionId, tc.bidder, tc.amount)
+		if !errors.Is(err, ErrNoAuction) {
+			t.Errorf("%s: got %v, want ErrNoAuction", tc.name, err)
+		}
+		if a != (Auction{}) {
+			t.Errorf("%s: returned auction %+v", tc.name, a)
+		}
+	}
+
+	var n int
+	if err := st.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM auction_bids WHERE auction_id = ?`, auctionId).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("%d bids recorded, want 0", n)
+	}
+}
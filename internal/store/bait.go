@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const baitSchema = `
+	CREATE TABLE IF NOT EXISTS bait_owned (
+		guild_id  BIGINT  NOT NULL,
+		user_id   BIGINT  NOT NULL,
+		bait_key  TEXT    NOT NULL,
+		quantity  INTEGER NOT NULL CHECK (quantity >= 0),
+		PRIMARY KEY (guild_id, user_id, bait_key)
+	);
+`
+
+// BaitCounts returns how many of each bait the user holds (zero counts are
+// omitted)
+func (s *SQLiteStore) BaitCounts(ctx context.Context, guildId, userId int64) (map[string]int, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT bait_key, quantity FROM bait_owned
+		WHERE guild_id = ? AND user_id = ? AND quantity > 0
+	`, guildId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]int)
+	for rows.Next() {
+		var (
+			key string
+			qty int
+		)
+		if err := rows.Scan(&key, &qty); err != nil {
+			return nil, err
+		}
+		out[key] = qty
+	}
+	return out, rows.Err()
+}
+
+// BuyBait charges the user for a pack of bait and adds it to their stock
+func (s *SQLiteStore) BuyBait(ctx context.Context, guildId, userId int64, key string, quantity int, price int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := post(ctx, tx, guildId, "buy_bait", fmt.Sprintf("bought %dx %s", quantity, key), time.Now(),
+		Posting{Account: UserAccount(userId), Amount: -price},
+		Posting{Account: AccountShop, Amount: price},
+	); err != nil {
+		return err
+	}
+
+	if err := addBaitTx(ctx, tx, guildId, userId, key, quantity); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func addBaitTx(ctx context.Context, q querier, guildId, userId int64, key string, quantity int) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO bait_owned (guild_id, user_id, bait_key, quantity) VALUES (?,?,?,?)
+		ON CONFLICT (guild_id, user_id, bait_key) DO UPDATE SET quantity = quantity + excluded.quantity
+	`, guildId, userId, key, quantity)
+	return err
+}
+
+// ConsumeBait uses one bait. It reports false if the user had none left.
+func (s *SQLiteStore) ConsumeBait(ctx context.Context, guildId, userId int64, key string) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, errors.New("store not initialized")
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE bait_owned SET quantity = quantity - 1
+		WHERE guild_id = ? AND user_id = ? AND bait_key = ? AND quantity > 0
+	`, guildId, userId, key)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Conservation points are earned by releasing catches and spent on titles.
+// They're a separate balance from coins and never go through the ledger.
+const conservationSchema = `
+	CREATE TABLE IF NOT EXISTS conservation_points (
+		guild_id  BIGINT  NOT NULL,
+		user_id   BIGINT  NOT NULL,
+		earned    INTEGER NOT NULL DEFAULT 0,
+		spent     INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (guild_id, user_id)
+	);
+`
+
+var (
+	ErrNotEnoughPoints  = errors.New("not enough conservation points")
+	ErrAlreadyRedeemed  = errors.New("title already redeemed")
+	ErrCatchNotReleased = errors.New("catch can't be released")
+)
+
+// ReleaseCatch lets a catch go and credits the user with points. Only the
+// angler who landed the fish can release it, and only while they still own it
+// and it hasn't been sold or put up for auction. Fails with
+// ErrCatchNotReleased otherwise, including when it was already released.
+func (s *SQLiteStore) ReleaseCatch(ctx context.Context, guildId, userId, catchId, points int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE catches SET released_at = ?
+		WHERE id = ? AND guild_id = ? AND user_id = ? AND caught_by = user_id
+			AND sold_at IS NULL AND released_at IS NULL AND `+notAuctioned,
+		time.Now().Unix(), catchId, guildId, userId)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return ErrCatchNotReleased
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO conservation_points (guild_id, user_id, earned) VALUES (?,?,?)
+		ON CONFLICT (guild_id, user_id) DO UPDATE SET earned = earned + excluded.earned
+	`, guildId, userId, points); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConservationPoints returns the user's unspent points
+func (s *SQLiteStore) ConservationPoints(ctx context.Context, guildId, userId int64) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+	return conservationBalance(ctx, s.db, guildId, userId)
+}
+
+func conservationBalance(ctx context.Context, q querier, guildId, userId int64) (int64, error) {
+	var points int64
+	err := q.QueryRowContext(ctx, `
+		SELECT earned - spent FROM conservation_points WHERE guild_id = ? AND user_id = ?
+	`, guildId, userId).Scan(&points)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return points, err
+}
+
+// RedeemTitle spends points on a title achievement and awards it, in one
+// transaction. Fails with ErrAlreadyRedeemed if the user has it already, or
+// ErrNotEnoughPoints if they can't afford it.
+func (s *SQLiteStore) RedeemTitle(ctx context.Context, guildId, userId int64, key string, cost int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO achievements (guild_id, user_id, key, earned_at) VALUES (?,?,?,?)
+		ON CONFLICT DO NOTHING
+	`, guildId, userId, key, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return ErrAlreadyRedeemed
+	}
+
+	points, err := conservationBalance(ctx, tx, guildId, userId)
+	if err != nil {
+		return err
+	}
+	if points < cost {
+		return ErrNotEnoughPoints
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE conservation_points SET spent = spent + ? WHERE guild_id = ? AND user_id = ?
+	`, cost, guildId, userId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// One row per /daily claim. day is the guild-local day number (see
+// fish.DayNumber), so the primary key enforces one claim per local day.
+const dailySchema = `
+	CREATE TABLE IF NOT EXISTS daily_claims (
+		guild_id    BIGINT  NOT NULL,
+		user_id     BIGINT  NOT NULL,
+		day         INTEGER NOT NULL,
+		streak      INTEGER NOT NULL,
+		coins       INTEGER NOT NULL,
+		bait_key    TEXT,
+		bait_qty    INTEGER NOT NULL DEFAULT 0,
+		claimed_at  INTEGER NOT NULL,
+		PRIMARY KEY (guild_id, user_id, day)
+	);
+`
+
+var ErrAlreadyClaimed = errors.New("already claimed today")
+
+// DailyClaim is a /daily payout
+type DailyClaim struct {
+	Day     int64
+	Streak  int
+	Coins   int64
+	BaitKey string // "" for no bait
+	BaitQty int
+}
+
+// ClaimDaily records the claim and pays it out in one transaction. Fails
+// with ErrAlreadyClaimed if the user already claimed on that day.
+func (s *SQLiteStore) ClaimDaily(ctx context.Context, guildId, userId int64, claim DailyClaim) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO daily_claims (guild_id, user_id, day, streak, coins, bait_key, bait_qty, claimed_at)
+		VALUES (?,?,?,?,?,?,?,?)
+		ON CONFLICT DO NOTHING
+	`, guildId, userId, claim.Day, claim.Streak, claim.Coins,
+		sql.NullString{String: claim.BaitKey, Valid: claim.BaitKey != ""}, claim.BaitQty, now.Unix())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAlreadyClaimed
+	}
+
+	if claim.Coins > 0 {
+		if _, err := post(ctx, tx, guildId, "daily", fmt.Sprintf("daily reward, %d-day streak", claim.Streak), now,
+			Posting{Account: AccountRewards, Amount: -claim.Coins},
+			Posting{Account: UserAccount(userId), Amount: claim.Coins},
+		); err != nil {
+			return err
+		}
+	}
+	if claim.BaitKey != "" && claim.BaitQty > 0 {
+		if err := addBaitTx(ctx, tx, guildId, userId, claim.BaitKey, claim.BaitQty); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LastDailyClaim returns the day number of the user's latest claim, or -1
+func (s *SQLiteStore) LastDailyClaim(ctx context.Context, guildId, userId int64) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+
+	var day sql.NullInt64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT MAX(day) FROM daily_claims WHERE guild_id = ? AND user_id = ?`,
+		guildId, userId,
+	).Scan(&day)
+	if err != nil {
+		return 0, err
+	}
+	if !day.Valid {
+		return -1, nil
+	}
+	return day.Int64, nil
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const gearSchema = `
+	CREATE TABLE IF NOT EXISTS gear_owned (
+		guild_id     BIGINT  NOT NULL,
+		user_id      BIGINT  NOT NULL,
+		item_key     TEXT    NOT NULL,
+		acquired_at  INTEGER NOT NULL,
+		PRIMARY KEY (guild_id, user_id, item_key)
+	);
+
+	CREATE TABLE IF NOT EXISTS gear_equipped (
+		guild_id  BIGINT NOT NULL,
+		user_id   BIGINT NOT NULL,
+		slot      TEXT   NOT NULL,
+		item_key  TEXT   NOT NULL,
+		PRIMARY KEY (guild_id, user_id, slot)
+	);
+`
+
+const AccountShop = "system:shop"
+
+var ErrAlreadyOwned = errors.New("already owned")
+
+// Loadout returns the user's equipped gear keys by slot
+func (s *SQLiteStore) Loadout(ctx context.Context, guildId, userId int64) (map[string]string, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT slot, item_key FROM gear_equipped WHERE guild_id = ? AND user_id = ?`,
+		guildId, userId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]string)
+	for rows.Next() {
+		var slot, key string
+		if err := rows.Scan(&slot, &key); err != nil {
+			return nil, err
+		}
+		out[slot] = key
+	}
+	return out, rows.Err()
+}
+
+// OwnedGear returns the set of gear keys the user has bought
+func (s *SQLiteStore) OwnedGear(ctx context.Context, guildId, userId int64) (map[string]bool, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT item_key FROM gear_owned WHERE guild_id = ? AND user_id = ?`,
+		guildId, userId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		out[key] = true
+	}
+	return out, rows.Err()
+}
+
+// BuyGear charges the user and equips the item in its slot, in one
+// transaction. Fails with ErrAlreadyOwned or ErrInsufficientFunds.
+func (s *SQLiteStore) BuyGear(ctx context.Context, guildId, userId int64, key, slot string, price int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO gear_owned (guild_id, user_id, item_key, acquired_at) VALUES (?,?,?,?)
+		ON CONFLICT DO NOTHING
+	`, guildId, userId, key, now.Unix())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAlreadyOwned
+	}
+
+	if _, err := post(ctx, tx, guildId, "buy_gear", fmt.Sprintf("bought %s", key), now,
+		Posting{Account: UserAccount(userId), Amount: -price},
+		Posting{Account: AccountShop, Amount: price},
+	); err != nil {
+		return err
+	}
+
+	if err := equipTx(ctx, tx, guildId, userId, key, slot); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EquipGear puts an owned item into its slot
+func (s *SQLiteStore) EquipGear(ctx context.Context, guildId, userId int64, key, slot string) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	var owned int
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM gear_owned WHERE guild_id = ? AND user_id = ? AND item_key = ?
+	`, guildId, userId, key).Scan(&owned); err != nil {
+		return err
+	}
+	if owned == 0 {
+		return fmt.Errorf("gear %q not owned", key)
+	}
+	return equipTx(ctx, s.db, guildId, userId, key, slot)
+}
+
+func equipTx(ctx context.Context, q querier, guildId, userId int64, key, slot string) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO gear_equipped (guild_id, user_id, slot, item_key) VALUES (?,?,?,?)
+		ON CONFLICT (guild_id, user_id, slot) DO UPDATE SET item_key = excluded.item_key
+	`, guildId, userId, slot, key)
+	return err
+}
@@ -0,0 +1,193 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/fish"
+)
+
+// The global leaderboard is a materialized top-N: every scope (a species, or
+// GlobalOverall for every species) keeps at most GlobalTopSize rows, so reading
+// it never touches catches. Rows are offered as fish are landed and the
+// smallest are trimmed off. Guilds that opt out are removed and never
+// offered; their catches from before opting back in stay off the board.
+const globalSchema = `
+	CREATE TABLE IF NOT EXISTS global_top (
+		scope        INTEGER NOT NULL,
+		catch_id     INTEGER NOT NULL,
+		guild_id     BIGINT  NOT NULL,
+		user_id      BIGINT  NOT NULL,
+		species_id   INTEGER NOT NULL,
+		size_tenths  INTEGER NOT NULL,
+		caught_at    INTEGER NOT NULL,
+		name         TEXT,
+		PRIMARY KEY (scope, catch_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_global_top
+		ON global_top (scope, size_tenths DESC, catch_id DESC);
+`
+
+const (
+	GlobalOverall = -1  // scope of the board across every species
+	GlobalTopSize = 100 // rows kept per scope; more than is ever shown, so opt-outs leave slack
+)
+
+// GlobalEntry is a catch on the global leaderboard. Name is the angler's
+// display name when the catch was landed, "" if it's not known.
+type GlobalEntry struct {
+	fish.Catch
+	Name string
+}
+
+// backfillGlobalTop fills an empty global board from every catch so far. It
+// scans catches once, on the first start with the board in place.
+func backfillGlobalTop(db *sql.DB) error {
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM global_top`).Scan(&n); err != nil || n > 0 {
+		return err
+	}
+
+	_, err := db.Exec(`
+		WITH eligible AS (
+			SELECT id, guild_id, caught_by, species_id, size_tenths, caught_at
+			FROM catches
+			WHERE guild_id NOT IN (SELECT guild_id FROM guild_settings WHERE global_opt_out = 1)
+		)
+		INSERT INTO global_top (scope, catch_id, guild_id, user_id, species_id, size_tenths, caught_at)
+		SELECT scope, id, guild_id, caught_by, species_id, size_tenths, caught_at FROM (
+			SELECT *, species_id AS scope,
+				ROW_NUMBER() OVER (PARTITION BY species_id ORDER BY size_tenths DESC, id DESC) AS pos
+			FROM eligible
+			UNION ALL
+			SELECT *, ? AS scope,
+				ROW_NUMBER() OVER (ORDER BY size_tenths DESC, id DESC) AS pos
+			FROM eligible
+		)
+		WHERE pos <= ?
+	`, GlobalOverall, GlobalTopSize)
+	return err
+}
+
+// OfferGlobalTop puts a stored catch on the global board if it's big enough
+// and its guild hasn't opted out. c.Id must be set.
+func (s *SQLiteStore) OfferGlobalTop(ctx context.Context, c fish.Catch, name string) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var optedOut bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT global_opt_out FROM guild_settings WHERE guild_id = ?`, c.GuildId,
+	).Scan(&optedOut)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if optedOut {
+		return nil
+	}
+
+	sizeTenths := int64(math.Round(c.Size * 10.0))
+	for _, scope := range []int64{int64(c.SpeciesId), GlobalOverall} {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO global_top (scope, catch_id, guild_id, user_id, species_id, size_tenths, caught_at, name)
+			VALUES (?,?,?,?,?,?,?,?)
+			ON CONFLICT DO NOTHING
+		`, scope, c.Id, c.GuildId, c.UserId, c.SpeciesId, sizeTenths, c.CaughtAt.Unix(),
+			sql.NullString{String: name, Valid: name != ""}); err != nil {
+			return err
+		}
+		// A scope holds at most GlobalTopSize+1 rows here, so this stays cheap
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM global_top
+			WHERE scope = ? AND catch_id NOT IN (
+				SELECT catch_id FROM global_top
+				WHERE scope = ?
+				ORDER BY size_tenths DESC, catch_id DESC
+				LIMIT ?
+			)
+		`, scope, scope, GlobalTopSize); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GlobalTop returns the biggest catches across guilds for a species, or for
+// every species with GlobalOverall
+func (s *SQLiteStore) GlobalTop(ctx context.Context, scope int64, limit int) ([]GlobalEntry, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT catch_id, guild_id, user_id, species_id, size_tenths, caught_at, name
+		FROM global_top
+		WHERE scope = ?
+		ORDER BY size_tenths DESC, catch_id DESC
+		LIMIT ?
+	`, scope, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []GlobalEntry
+	for rows.Next() {
+		var (
+			e                    GlobalEntry
+			spid                 int
+			sizeTenths, caughtAt int64
+			name                 sql.NullString
+		)
+		if err := rows.Scan(&e.Id, &e.GuildId, &e.UserId, &spid, &sizeTenths, &caughtAt, &name); err != nil {
+			return nil, err
+		}
+		e.SpeciesId = fish.SpeciesId(spid)
+		e.Size = float64(sizeTenths) / 10.0
+		e.CaughtAt = time.Unix(caughtAt, 0).UTC()
+		e.Name = name.String
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// SetGlobalOptOut keeps a guild's catches off the global leaderboard, or
+// lets new ones back on. Opting out removes the guild's rows straight away.
+func (s *SQLiteStore) SetGlobalOptOut(ctx context.Context, guildId int64, out bool) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO guild_settings (guild_id, global_opt_out) VALUES (?,?)
+		ON CONFLICT (guild_id) DO UPDATE SET global_opt_out = excluded.global_opt_out
+	`, guildId, out); err != nil {
+		return err
+	}
+	if out {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM global_top WHERE guild_id = ?`, guildId); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
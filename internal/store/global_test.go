@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/fish"
+)
+
+func openTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	st, err := OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+func TestOfferGlobalTopStoresName(t *testing.T) {
+	st := openTestStore(t)
+	ctx := context.Background()
+
+	catches := []struct {
+		name string
+		size float64
+	}{
+		{"Ahab", 120.5},
+		{"", 80}, // no name known
+	}
+	for _, tc := range catches {
+		c := fish.Catch{GuildId: 1, UserId: 2, SpeciesId: 3, Size: tc.size, CaughtAt: time.Unix(1_700_000_000, 0)}
+		id, err := st.Add(ctx, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Id = id
+		if err := st.OfferGlobalTop(ctx, c, tc.name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, scope := range []int64{3, GlobalOverall} {
+		top, err := st.GlobalTop(ctx, scope, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(top) != len(catches) {
+			t.Fatalf("scope %d has %d entries, want %d", scope, len(top), len(catches))
+		}
+		for idx, want := range catches {
+			if top[idx].Name != want.name || top[idx].Size != want.size {
+				t.Errorf("scope %d entry %d = %q %.1f cm, want %q %.1f cm", scope, idx, top[idx].Name, top[idx].Size, want.name, want.size)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const guildSchema = `
+	CREATE TABLE IF NOT EXISTS guild_settings (
+		guild_id             BIGINT  PRIMARY KEY,
+		fishing_cd_min_secs  INTEGER,
+		fishing_cd_max_secs  INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS guild_templates (
+		guild_id  BIGINT NOT NULL,
+		name      TEXT   NOT NULL,
+		body      TEXT   NOT NULL,
+		PRIMARY KEY (guild_id, name)
+	);
+`
+
+// GuildSettings holds per-guild overrides. Zero values mean "use the
+// bot-wide default".
+type GuildSettings struct {
+	GuildId            int64
+	FishingCooldownMin time.Duration
+	FishingCooldownMax time.Duration
+	Timezone           string            // IANA name, "" for UTC
+	Reeling            bool              // /fish waits for a bite and a Reel press
+	Fights             bool              // huge fish have to be fought in before they land
+	GlobalOptOut       bool              // keep catches off the cross-server leaderboard
+	Templates          map[string]string // message template overrides by name (see view.Overrides)
+}
+
+func (s *SQLiteStore) GuildSettings(ctx context.Context, guildId int64) (GuildSettings, error) {
+	if s == nil || s.db == nil {
+		return GuildSettings{}, errors.New("store not initialized")
+	}
+
+	out := GuildSettings{GuildId: guildId}
+	var (
+		cdMin, cdMax sql.NullInt64
+		tz           sql.NullString
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT fishing_cd_min_secs, fishing_cd_max_secs, timezone, reeling, fights, global_opt_out
+		FROM guild_settings
+		WHERE guild_id = ?
+	`, guildId).Scan(&cdMin, &cdMax, &tz, &out.Reeling, &out.Fights, &out.GlobalOptOut)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return out, err
+	}
+
+	if cdMin.Valid && cdMax.Valid {
+		out.FishingCooldownMin = time.Duration(cdMin.Int64) * time.Second
+		out.FishingCooldownMax = time.Duration(cdMax.Int64) * time.Second
+	}
+	out.Timezone = tz.String
+
+	out.Templates, err = s.guildTemplates(ctx, guildId)
+	return out, err
+}
+
+func (s *SQLiteStore) guildTemplates(ctx context.Context, guildId int64) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT name, body FROM guild_templates WHERE guild_id = ?`, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]string)
+	for rows.Next() {
+		var name, body string
+		if err := rows.Scan(&name, &body); err != nil {
+			return nil, err
+		}
+		out[name] = body
+	}
+	return out, rows.Err()
+}
+
+// SetFishingCooldown stores a per-guild fishing cooldown range. Passing zero
+// for both bounds clears the override.
+func (s *SQLiteStore) SetFishingCooldown(ctx context.Context, guildId int64, min, max time.Duration) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	var cdMin, cdMax sql.NullInt64
+	if min > 0 || max > 0 {
+		cdMin = sql.NullInt64{Int64: int64(min / time.Second), Valid: true}
+		cdMax = sql.NullInt64{Int64: int64(max / time.Second), Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO guild_settings (guild_id, fishing_cd_min_secs, fishing_cd_max_secs)
+		VALUES (?,?,?)
+		ON CONFLICT (guild_id) DO UPDATE SET
+			fishing_cd_min_secs = excluded.fishing_cd_min_secs,
+			fishing_cd_max_secs = excluded.fishing_cd_max_secs
+	`, guildId, cdMin, cdMax)
+	return err
+}
+
+// SetTimezone stores the guild's IANA timezone name; "" resets it to UTC
+func (s *SQLiteStore) SetTimezone(ctx context.Context, guildId int64, tz string) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO guild_settings (guild_id, timezone) VALUES (?,?)
+		ON CONFLICT (guild_id) DO UPDATE SET timezone = excluded.timezone
+	`, guildId, sql.NullString{String: tz, Valid: tz != ""})
+	return err
+}
+
+// SetReeling turns the reeling minigame on /fish on or off
+func (s *SQLiteStore) SetReeling(ctx context.Context, guildId int64, on bool) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO guild_settings (guild_id, reeling) VALUES (?,?)
+		ON CONFLICT (guild_id) DO UPDATE SET reeling = excluded.reeling
+	`, guildId, on)
+	return err
+}
+
+// SetFights turns line-tension fights for huge fish on or off
+func (s *SQLiteStore) SetFights(ctx context.Context, guildId int64, on bool) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO guild_settings (guild_id, fights) VALUES (?,?)
+		ON CONFLICT (guild_id) DO UPDATE SET fights = excluded.fights
+	`, guildId, on)
+	return err
+}
+
+// SetTemplate stores a guild's override for a message template; an empty
+// body goes back to the default
+func (s *SQLiteStore) SetTemplate(ctx context.Context, guildId int64, name, body string) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	if body == "" {
+		_, err := s.db.ExecContext(ctx,
+			`DELETE FROM guild_templates WHERE guild_id = ? AND name = ?`, guildId, name)
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO guild_templates (guild_id, name, body) VALUES (?,?,?)
+		ON CONFLICT (guild_id, name) DO UPDATE SET body = excluded.body
+	`, guildId, name, body)
+	return err
+}
@@ -0,0 +1,303 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/fish"
+)
+
+// market_sales counts sales per species per day so prices can react to
+// supply. market_prices keeps a daily snapshot of each species' market value
+// for trend lines. Rows with guild_id 0 belong to the global market.
+const marketSchema = `
+	CREATE TABLE IF NOT EXISTS market_sales (
+		guild_id    BIGINT  NOT NULL,
+		species_id  INTEGER NOT NULL,
+		day         INTEGER NOT NULL,
+		sold        INTEGER NOT NULL,
+		PRIMARY KEY (guild_id, day, species_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS market_prices (
+		guild_id    BIGINT  NOT NULL,
+		species_id  INTEGER NOT NULL,
+		day         INTEGER NOT NULL,
+		price       INTEGER NOT NULL,
+		PRIMARY KEY (guild_id, day, species_id)
+	);
+`
+
+// Sale prices one catch for SellCatches
+type Sale struct {
+	CatchId   int64
+	SpeciesId fish.SpeciesId
+	Price     int64
+}
+
+// Day numbers are days since the unix epoch in UTC
+func Day(t time.Time) int64 {
+	return t.Unix() / 86400
+}
+
+// unsoldWhere matches a user's catches in a guild that have not been sold or
+// released and aren't up for auction. Its arguments are guildId, userId and
+// speciesId twice; a negative speciesId matches every species.
+const unsoldWhere = `guild_id = ? AND user_id = ? AND sold_at IS NULL AND released_at IS NULL
+			AND (? < 0 OR species_id = ?) AND ` + notAuctioned
+
+// UnsoldCatches returns a user's catches in a guild that have not been sold
+// or released and aren't up for auction, newest first.
+// Pass a negative speciesId to include every species.
+func (s *SQLiteStore) UnsoldCatches(ctx context.Context, guildId, userId int64, speciesId fish.SpeciesId) ([]fish.Catch, error) {
+	return s.UnsoldPage(ctx, guildId, userId, speciesId, false, -1, 0)
+}
+
+// UnsoldPage is UnsoldCatches a page at a time: limit catches after skipping
+// offset, newest first or, with biggest, largest first. A negative limit
+// returns every catch.
+func (s *SQLiteStore) UnsoldPage(ctx context.Context, guildId, userId int64, speciesId fish.SpeciesId, biggest bool, limit, offset int) ([]fish.Catch, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	order := "id DESC"
+	if biggest {
+		order = "size_tenths DESC, id DESC"
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, guild_id, user_id, species_id, size_tenths, caught_at
+		FROM catches
+		WHERE `+unsoldWhere+`
+		ORDER BY `+order+`
+		LIMIT ? OFFSET ?
+	`, guildId, userId, speciesId, speciesId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []fish.Catch
+	for rows.Next() {
+		var (
+			id, gid, uid int64
+			spid         int
+			sizeTenths   int64
+			caughtUnix   int64
+		)
+		if err := rows.Scan(&id, &gid, &uid, &spid, &sizeTenths, &caughtUnix); err != nil {
+			return nil, err
+		}
+
+		out = append(out, fish.Catch{
+			Id:        id,
+			GuildId:   gid,
+			UserId:    uid,
+			SpeciesId: fish.SpeciesId(spid),
+			Size:      float64(sizeTenths) / 10.0,
+			CaughtAt:  time.Unix(caughtUnix, 0).UTC(),
+		})
+	}
+
+	return out, rows.Err()
+}
+
+// SizeCount is how many of a user's unsold catches share a species and size
+type SizeCount struct {
+	SpeciesId fish.SpeciesId
+	Size      float64 // cm
+	N         int
+}
+
+// UnsoldSizes summarizes the catches UnsoldCatches would return by species
+// and size, which is all it takes to count and price them.
+func (s *SQLiteStore) UnsoldSizes(ctx context.Context, guildId, userId int64, speciesId fish.SpeciesId) ([]SizeCount, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT species_id, size_tenths, COUNT(*)
+		FROM catches
+		WHERE `+unsoldWhere+`
+		GROUP BY species_id, size_tenths
+	`, guildId, userId, speciesId, speciesId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []SizeCount
+	for rows.Next() {
+		var (
+			spid       int
+			sizeTenths int64
+			n          int
+		)
+		if err := rows.Scan(&spid, &sizeTenths, &n); err != nil {
+			return nil, err
+		}
+		out = append(out, SizeCount{SpeciesId: fish.SpeciesId(spid), Size: float64(sizeTenths) / 10.0, N: n})
+	}
+	return out, rows.Err()
+}
+
+// SellCatches marks the given catches as sold and pays the user from the
+// market account, all in one transaction. Catches that are not owned by the
+// user or were already sold are skipped. Sold catches are kept so they still
+// count for leaderboards. Returns the sales that went through and the total
+// paid.
+func (s *SQLiteStore) SellCatches(ctx context.Context, guildId, userId int64, sales []Sale) ([]Sale, int64, error) {
+	if s == nil || s.db == nil {
+		return nil, 0, errors.New("store not initialized")
+	}
+	if len(sales) == 0 {
+		return nil, 0, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	// Mark the catches first so only the ones actually sold are paid for,
+	// then link them to the ledger transaction for auditing.
+	var sold []Sale
+	var total int64
+	for _, sale := range sales {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE catches SET sold_at = ?, sold_price = ?
+			WHERE id = ? AND guild_id = ? AND user_id = ? AND sold_at IS NULL AND released_at IS NULL AND `+notAuctioned,
+			now.Unix(), sale.Price, sale.CatchId, guildId, userId)
+		if err != nil {
+			return nil, 0, err
+		}
+		if n, _ := res.RowsAffected(); n != 1 {
+			continue
+		}
+		sold = append(sold, sale)
+		total += sale.Price
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO market_sales (guild_id, species_id, day, sold) VALUES (?,?,?,1)
+			ON CONFLICT (guild_id, day, species_id) DO UPDATE SET sold = sold + 1
+		`, guildId, sale.SpeciesId, Day(now)); err != nil {
+			return nil, 0, err
+		}
+	}
+	if len(sold) == 0 {
+		return nil, 0, nil
+	}
+
+	txnId, err := post(ctx, tx, guildId, "sell", fmt.Sprintf("sold %d fish", len(sold)), now,
+		Posting{Account: AccountMarket, Amount: -total},
+		Posting{Account: UserAccount(userId), Amount: total},
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args := []any{txnId}
+	for _, sale := range sold {
+		args = append(args, sale.CatchId)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE catches SET sold_txn_id = ?
+		WHERE id IN (?`+strings.Repeat(",?", len(sold)-1)+`)
+	`, args...); err != nil {
+		return nil, 0, err
+	}
+
+	return sold, total, tx.Commit()
+}
+
+// SalesByDay returns sale counts per species per day since sinceDay. A
+// guildId of 0 sums sales across every guild.
+func (s *SQLiteStore) SalesByDay(ctx context.Context, guildId, sinceDay int64) (map[fish.SpeciesId]map[int64]int64, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT species_id, day, SUM(sold)
+		FROM market_sales
+		WHERE (? = 0 OR guild_id = ?) AND day >= ?
+		GROUP BY species_id, day
+	`, guildId, guildId, sinceDay)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSpeciesDays(rows)
+}
+
+// SavePrices records the given day's price snapshot. A day that already has
+// a snapshot keeps it.
+func (s *SQLiteStore) SavePrices(ctx context.Context, guildId, day int64, prices map[fish.SpeciesId]int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for sp, price := range prices {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO market_prices (guild_id, species_id, day, price) VALUES (?,?,?,?)
+			ON CONFLICT (guild_id, day, species_id) DO NOTHING
+		`, guildId, sp, day, price); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// PriceHistory returns recorded prices per species per day since sinceDay
+func (s *SQLiteStore) PriceHistory(ctx context.Context, guildId, sinceDay int64) (map[fish.SpeciesId]map[int64]int64, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT species_id, day, price
+		FROM market_prices
+		WHERE guild_id = ? AND day >= ?
+	`, guildId, sinceDay)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSpeciesDays(rows)
+}
+
+func scanSpeciesDays(rows *sql.Rows) (map[fish.SpeciesId]map[int64]int64, error) {
+	out := make(map[fish.SpeciesId]map[int64]int64)
+	for rows.Next() {
+		var (
+			spid int
+			day  int64
+			n    int64
+		)
+		if err := rows.Scan(&spid, &day, &n); err != nil {
+			return nil, err
+		}
+		sp := fish.SpeciesId(spid)
+		if out[sp] == nil {
+			out[sp] = make(map[int64]int64)
+		}
+		out[sp][day] = n
+	}
+	return out, rows.Err()
+}
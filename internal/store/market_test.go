@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/fish"
+)
+
+func TestUnsoldPage(t *testing.T) {
+	st := openTestStore(t)
+	ctx := context.Background()
+
+	// Oldest first; ids follow the same order
+	sizes := []float64{30, 10, 50, 20, 40}
+	ids := make(map[float64]int64)
+	for n, size := range sizes {
+		c := fish.Catch{GuildId: 1, UserId: 2, SpeciesId: fish.SpeciesId(n % 2), Size: size, CaughtAt: time.Unix(1_700_000_000+int64(n), 0)}
+		id, err := st.Add(ctx, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[size] = id
+	}
+	// Someone else's catch never shows up
+	if _, err := st.Add(ctx, fish.Catch{GuildId: 1, UserId: 3, SpeciesId: 0, Size: 99, CaughtAt: time.Unix(1_700_000_000, 0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		species       fish.SpeciesId
+		biggest       bool
+		limit, offset int
+		want          []float64
+	}{
+		{"newest", -1, false, 2, 0, []float64{40, 20}},
+		{"newest page 2", -1, false, 2, 2, []float64{50, 10}},
+		{"biggest", -1, true, 2, 0, []float64{50, 40}},
+		{"biggest last page", -1, true, 2, 4, []float64{10}},
+		{"one species", 0, true, -1, 0, []float64{50, 40, 30}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := st.UnsoldPage(ctx, 1, 2, tt.species, tt.biggest, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []float64
+			for _, c := range page {
+				if c.Id != ids[c.Size] {
+					t.Errorf("%.0f cm catch has id %d, want %d", c.Size, c.Id, ids[c.Size])
+				}
+				got = append(got, c.Size)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got sizes %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	counts, err := st.UnsoldSizes(ctx, 1, 2, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, sc := range counts {
+		n += sc.N
+	}
+	if n != len(sizes) {
+		t.Errorf("UnsoldSizes counts %d catches, want %d", n, len(sizes))
+	}
+}
+
+func TestSavePricesKeepsFirstSnapshot(t *testing.T) {
+	st := openTestStore(t)
+	ctx := context.Background()
+
+	for _, price := range []int64{100, 80} {
+		if err := st.SavePrices(ctx, 1, 20_000, map[fish.SpeciesId]int64{3: price}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history, err := st.PriceHistory(ctx, 1, 20_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := history[3][20_000]; got != 100 {
+		t.Errorf("price = %d, want the first snapshot's 100", got)
+	}
+}
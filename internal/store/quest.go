@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Quests are defined in data (see fish.QuestCatalog); each user's rolled
+// quests are stored per period ("d<day>" or "w<week>"). target and reward are
+// copied from the quest when it's assigned, so editing the data file doesn't
+// change quests already in progress.
+const questSchema = `
+	CREATE TABLE IF NOT EXISTS user_quests (
+		guild_id      BIGINT  NOT NULL,
+		user_id       BIGINT  NOT NULL,
+		period        TEXT    NOT NULL,
+		quest_key     TEXT    NOT NULL,
+		progress      INTEGER NOT NULL DEFAULT 0,
+		target        INTEGER NOT NULL,
+		reward        INTEGER NOT NULL,
+		completed_at  INTEGER,
+		PRIMARY KEY (guild_id, user_id, period, quest_key)
+	);
+`
+
+// QuestAssignment is a quest rolled for a user in one period
+type QuestAssignment struct {
+	Period      string
+	Key         string
+	Progress    int64
+	Target      int64
+	Reward      int64
+	CompletedAt time.Time // zero while in progress
+}
+
+// QuestProgress adds Amount progress to one assigned quest
+type QuestProgress struct {
+	Period string
+	Key    string
+	Amount int64
+}
+
+// EnsureQuests assigns the given quests for a period unless the user already
+// has quests for it, and returns the user's quests for that period.
+func (s *SQLiteStore) EnsureQuests(ctx context.Context, guildId, userId int64, period string, quests []QuestAssignment) ([]QuestAssignment, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var have int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM user_quests WHERE guild_id = ? AND user_id = ? AND period = ?`,
+		guildId, userId, period,
+	).Scan(&have); err != nil {
+		return nil, err
+	}
+	if have == 0 {
+		for _, q := range quests {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO user_quests (guild_id, user_id, period, quest_key, target, reward)
+				VALUES (?,?,?,?,?,?)
+				ON CONFLICT DO NOTHING
+			`, guildId, userId, period, q.Key, q.Target, q.Reward); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	out, err := questsFor(ctx, tx, guildId, userId, period)
+	if err != nil {
+		return nil, err
+	}
+	return out, tx.Commit()
+}
+
+// Quests returns the user's quests for a period, in the order assigned
+func (s *SQLiteStore) Quests(ctx context.Context, guildId, userId int64, period string) ([]QuestAssignment, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return questsFor(ctx, s.db, guildId, userId, period)
+}
+
+func questsFor(ctx context.Context, q querier, guildId, userId int64, period string) ([]QuestAssignment, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT quest_key, progress, target, reward, completed_at
+		FROM user_quests
+		WHERE guild_id = ? AND user_id = ? AND period = ?
+		ORDER BY rowid
+	`, guildId, userId, period)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []QuestAssignment
+	for rows.Next() {
+		a := QuestAssignment{Period: period}
+		var completed sql.NullInt64
+		if err := rows.Scan(&a.Key, &a.Progress, &a.Target, &a.Reward, &completed); err != nil {
+			return nil, err
+		}
+		if completed.Valid {
+			a.CompletedAt = time.Unix(completed.Int64, 0).UTC()
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// ProgressQuests applies progress to the user's quests and pays out every
+// quest it completes from the rewards account, all in one transaction.
+// Progress is capped at the target and completed quests don't move, so each
+// reward is paid exactly once. Returns the quests completed by this call.
+func (s *SQLiteStore) ProgressQuests(ctx context.Context, guildId, userId int64, progress []QuestProgress) ([]QuestAssignment, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	if len(progress) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var completed []QuestAssignment
+	for _, p := range progress {
+		if p.Amount <= 0 {
+			continue
+		}
+		a := QuestAssignment{Period: p.Period, Key: p.Key, CompletedAt: time.Unix(now.Unix(), 0).UTC()}
+		err := tx.QueryRowContext(ctx, `
+			UPDATE user_quests
+			SET progress = MIN(target, progress + ?),
+				completed_at = CASE WHEN progress + ? >= target THEN ? END
+			WHERE guild_id = ? AND user_id = ? AND period = ? AND quest_key = ? AND completed_at IS NULL
+			RETURNING progress, target, reward
+		`, p.Amount, p.Amount, now.Unix(), guildId, userId, p.Period, p.Key).
+			Scan(&a.Progress, &a.Target, &a.Reward)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if a.Progress < a.Target {
+			continue
+		}
+		completed = append(completed, a)
+
+		if a.Reward > 0 {
+			if _, err := post(ctx, tx, guildId, "quest", fmt.Sprintf("quest %s (%s)", p.Key, p.Period), now,
+				Posting{Account: AccountRewards, Amount: -a.Reward},
+				Posting{Account: UserAccount(userId), Amount: a.Reward},
+			); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return completed, tx.Commit()
+}
@@ -48,8 +48,8 @@ func OpenSQLite(dbPath string) (*SQLiteStore, error) {
 	}
 
 	ins, err := db.Prepare(`
-		INSERT INTO catches (guild_id, user_id, species_id, size_tenths, caught_at)
-		VALUES (?,?,?,?,?)
+		INSERT INTO catches (guild_id, user_id, caught_by, species_id, size_tenths, caught_at)
+		VALUES (?,?,?,?,?,?)
 	`)
 
 	if err != nil {
@@ -57,8 +57,9 @@ func OpenSQLite(dbPath string) (*SQLiteStore, error) {
 		return nil, err
 	}
 
+	// Leaderboards credit whoever caught the fish, not its current owner
 	top, err := db.Prepare(`
-		SELECT id, guild_id, user_id, species_id, size_tenths, caught_at
+		SELECT id, guild_id, caught_by, species_id, size_tenths, caught_at, released_at IS NOT NULL
 		FROM catches
 		WHERE guild_id = ?
 		ORDER BY size_tenths DESC, id DESC 
@@ -72,7 +73,7 @@ func OpenSQLite(dbPath string) (*SQLiteStore, error) {
 	}
 
 	topSpecies, err := db.Prepare(`
-		SELECT id, guild_id, user_id, species_id, size_tenths, caught_at
+		SELECT id, guild_id, caught_by, species_id, size_tenths, caught_at, released_at IS NOT NULL
 		FROM catches
 		WHERE guild_id = ? AND species_id = ?
 		ORDER BY size_tenths DESC, id DESC 
@@ -104,6 +105,12 @@ func (s *SQLiteStore) Close() error {
 	return s.db.Close()
 }
 
+// DB exposes the underlying handle so other packages (e.g. the ratelimit
+// SQLite backend) can share the same database file and connection pool.
+func (s *SQLiteStore) DB() *sql.DB {
+	return s.db
+}
+
 func initSchema(db *sql.DB) error {
 	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS catches (
@@ -120,12 +127,100 @@ func initSchema(db *sql.DB) error {
 		CREATE INDEX IF NOT EXISTS idx_leader_species
 			ON catches (guild_id, species_id, size_tenths DESC, id DESC);
 	`)
+	if err != nil {
+		return err
+	}
+
+	// Columns added to catches after the initial schema
+	for _, col := range []struct{ name, decl string }{
+		{"sold_at", "INTEGER"},
+		{"sold_price", "INTEGER"},
+		{"sold_txn_id", "INTEGER"},
+		{"caught_by", "BIGINT"},
+		{"released_at", "INTEGER"},
+	} {
+		if err := addColumnIfMissing(db, "catches", col.name, col.decl); err != nil {
+			return err
+		}
+	}
+
+	// user_id is the current owner, which trades and auctions change;
+	// caught_by remembers who reeled it in. Older rows never changed hands.
+	if _, err := db.Exec(`UPDATE catches SET caught_by = user_id WHERE caught_by IS NULL`); err != nil {
+		return err
+	}
+
+	// Per-user lookups (inventory, selling) only look at unsold catches, and
+	// the inventory pages through them newest or biggest first
+	if _, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_catches_user
+			ON catches (guild_id, user_id, sold_at, id DESC);
+		CREATE INDEX IF NOT EXISTS idx_catches_user_size
+			ON catches (guild_id, user_id, sold_at, size_tenths DESC, id DESC);
+		CREATE INDEX IF NOT EXISTS idx_catches_caught_by
+			ON catches (guild_id, caught_by, caught_at);
+	`); err != nil {
+		return err
+	}
+
+	// Feature tables live next to the code that uses them
+	for _, ddl := range []string{guildSchema, walletSchema, gearSchema, baitSchema, marketSchema, tradeSchema, auctionSchema, achievementSchema, dailySchema, questSchema, tournamentSchema, teamSchema, conservationSchema, globalSchema} {
+		if _, err := db.Exec(ddl); err != nil {
+			return err
+		}
+	}
+
+	// Columns added to feature tables after they were first created
+	for _, col := range []struct{ name, decl string }{
+		{"timezone", "TEXT"},
+		{"reeling", "INTEGER NOT NULL DEFAULT 0"},
+		{"fights", "INTEGER NOT NULL DEFAULT 0"},
+		{"global_opt_out", "INTEGER NOT NULL DEFAULT 0"},
+	} {
+		if err := addColumnIfMissing(db, "guild_settings", col.name, col.decl); err != nil {
+			return err
+		}
+	}
+	return backfillGlobalTop(db)
+}
+
+// addColumnIfMissing is a minimal migration for columns added after a table
+// was first created; SQLite has no ADD COLUMN IF NOT EXISTS.
+func addColumnIfMissing(db *sql.DB, table, column, decl string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name, typ string
+			notNull   int
+			dflt      sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl))
 	return err
 }
 
-func (s *SQLiteStore) Add(ctx context.Context, c fish.Catch) error {
+// Add stores a catch and returns its id
+func (s *SQLiteStore) Add(ctx context.Context, c fish.Catch) (int64, error) {
 	if s == nil || s.db == nil {
-		return errors.New("store not initialized")
+		return 0, errors.New("store not initialized")
 	}
 
 	if c.CaughtAt.IsZero() {
@@ -133,14 +228,18 @@ func (s *SQLiteStore) Add(ctx context.Context, c fish.Catch) error {
 	}
 
 	sizeTenths := int64(math.Round(c.Size * 10.0))
-	_, err := s.insertStmt.Exec(
+	res, err := s.insertStmt.Exec(
 		c.GuildId,
 		c.UserId,
+		c.UserId,
 		c.SpeciesId,
 		sizeTenths,
 		c.CaughtAt.Unix(),
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
 }
 
 func (s *SQLiteStore) TopBySize(ctx context.Context, guildId int64, limit int) ([]fish.Catch, error) {
@@ -165,8 +264,9 @@ func (s *SQLiteStore) TopBySize(ctx context.Context, guildId int64, limit int) (
 			spid         int
 			sizeTenths   int64
 			caughtUnix   int64
+			released     bool
 		)
-		if err := rows.Scan(&id, &gid, &uid, &spid, &sizeTenths, &caughtUnix); err != nil {
+		if err := rows.Scan(&id, &gid, &uid, &spid, &sizeTenths, &caughtUnix, &released); err != nil {
 			return nil, err
 		}
 
@@ -177,6 +277,7 @@ func (s *SQLiteStore) TopBySize(ctx context.Context, guildId int64, limit int) (
 			SpeciesId: fish.SpeciesId(spid),
 			Size:      float64(sizeTenths) / 10.0,
 			CaughtAt:  time.Unix(caughtUnix, 0).UTC(),
+			Released:  released,
 		})
 	}
 
@@ -205,8 +306,9 @@ func (s *SQLiteStore) TopBySizeGuildSpecies(ctx context.Context, guildId int64,
 			spid         int
 			sizeTenths   int64
 			caughtUnix   int64
+			released     bool
 		)
-		if err := rows.Scan(&id, &gid, &uid, &spid, &sizeTenths, &caughtUnix); err != nil {
+		if err := rows.Scan(&id, &gid, &uid, &spid, &sizeTenths, &caughtUnix, &released); err != nil {
 			return nil, err
 		}
 
@@ -217,6 +319,7 @@ func (s *SQLiteStore) TopBySizeGuildSpecies(ctx context.Context, guildId int64,
 			SpeciesId: fish.SpeciesId(spid),
 			Size:      float64(sizeTenths) / 10.0,
 			CaughtAt:  time.Unix(caughtUnix, 0).UTC(),
+			Released:  released,
 		})
 	}
 
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/fish"
+)
+
+// statsSlot is the granularity of UserStats.Active. Every timezone offset in
+// use is a multiple of 15 minutes, so slots never straddle a local midnight.
+const statsSlot = 15 * 60
+
+// SpeciesStats sums up one user's catches of a species
+type SpeciesStats struct {
+	Catches int
+	Biggest float64 // cm
+}
+
+// UserStats is everything a profile shows about a user in a guild. Catches
+// count everything the user reeled in, including fish since sold, traded or
+// released.
+type UserStats struct {
+	Catches  int
+	Released int
+	Species  map[fish.SpeciesId]SpeciesStats
+	Balance  int64
+	TitleKey string      // equipped title's achievement key, "" for none
+	Active   []time.Time // start of every 15 minute slot with a catch, for streaks
+}
+
+// UserStats gathers a user's profile stats in one read transaction
+func (s *SQLiteStore) UserStats(ctx context.Context, guildId, userId int64) (UserStats, error) {
+	if s == nil || s.db == nil {
+		return UserStats{}, errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return UserStats{}, err
+	}
+	defer tx.Rollback()
+
+	out := UserStats{Species: make(map[fish.SpeciesId]SpeciesStats)}
+	var (
+		balance sql.NullInt64
+		title   sql.NullString
+	)
+	if err := tx.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM catches WHERE guild_id = ?1 AND caught_by = ?2 AND released_at IS NOT NULL),
+			(SELECT balance FROM wallets WHERE guild_id = ?1 AND user_id = ?2),
+			(SELECT title_key FROM user_titles WHERE guild_id = ?1 AND user_id = ?2)
+	`, guildId, userId).Scan(&out.Released, &balance, &title); err != nil {
+		return out, err
+	}
+	out.Balance, out.TitleKey = balance.Int64, title.String
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT species_id, COUNT(*), MAX(size_tenths)
+		FROM catches
+		WHERE guild_id = ? AND caught_by = ?
+		GROUP BY species_id
+	`, guildId, userId)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			spid       int
+			n          int
+			sizeTenths int64
+		)
+		if err := rows.Scan(&spid, &n, &sizeTenths); err != nil {
+			return out, err
+		}
+		out.Species[fish.SpeciesId(spid)] = SpeciesStats{Catches: n, Biggest: float64(sizeTenths) / 10.0}
+		out.Catches += n
+	}
+	if err := rows.Err(); err != nil {
+		return out, err
+	}
+	rows.Close()
+
+	out.Active, err = activeSlots(ctx, tx, guildId, userId)
+	return out, err
+}
+
+// ActiveSlots returns the start of every 15 minute slot in which the user
+// caught something in the guild, which is all streaks need
+func (s *SQLiteStore) ActiveSlots(ctx context.Context, guildId, userId int64) ([]time.Time, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return activeSlots(ctx, s.db, guildId, userId)
+}
+
+func activeSlots(ctx context.Context, q querier, guildId, userId int64) ([]time.Time, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT DISTINCT caught_at / ? * ?
+		FROM catches
+		WHERE guild_id = ? AND caught_by = ?
+	`, statsSlot, statsSlot, guildId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []time.Time
+	for rows.Next() {
+		var at int64
+		if err := rows.Scan(&at); err != nil {
+			return nil, err
+		}
+		out = append(out, time.Unix(at, 0).UTC())
+	}
+	return out, rows.Err()
+}
+
+// CaughtSince counts the user's catches in the guild since a time
+func (s *SQLiteStore) CaughtSince(ctx context.Context, guildId, userId int64, since time.Time) (int, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+
+	var n int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM catches
+		WHERE guild_id = ? AND caught_by = ? AND caught_at >= ?
+	`, guildId, userId, since.Unix()).Scan(&n)
+	return n, err
+}
+
+// CatchTally counts every catch the user made in the guild, including ones
+// since sold, traded or released, by species and size class. Catches of
+// species no longer in reg are left out.
+func (s *SQLiteStore) CatchTally(ctx context.Context, guildId, userId int64, reg *fish.Registry) (fish.CatchTally, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	// Size classes depend on the species, so count each distinct size and
+	// classify them here
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT species_id, size_tenths, COUNT(*)
+		FROM catches
+		WHERE guild_id = ? AND caught_by = ?
+		GROUP BY species_id, size_tenths
+	`, guildId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(fish.CatchTally)
+	for rows.Next() {
+		var (
+			spid       int
+			sizeTenths int64
+			n          int
+		)
+		if err := rows.Scan(&spid, &sizeTenths, &n); err != nil {
+			return nil, err
+		}
+		sp, ok := reg.GetById(fish.SpeciesId(spid))
+		if !ok {
+			continue
+		}
+		counts := out[sp.Id]
+		counts[fish.SizeClassFor(sp, float64(sizeTenths)/10.0)] += n
+		out[sp.Id] = counts
+	}
+	return out, rows.Err()
+}
@@ -7,7 +7,7 @@ import (
 )
 
 type Store interface {
-	Add(ctx context.Context, c fish.Catch) error
+	Add(ctx context.Context, c fish.Catch) (int64, error)
 	AddBatch(ctx context.Context, cs []fish.Catch) error
 	TopBySize(ctx context.Context, guildId uint64, limit int) ([]fish.Catch, error)
 }
@@ -0,0 +1,302 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/fish"
+)
+
+// Team memberships are kept as history rather than overwritten: each row
+// covers [joined_at, left_at), so a catch always counts for the team its
+// catcher was on when it was caught. A user has at most one open membership
+// per guild.
+const teamSchema = `
+	CREATE TABLE IF NOT EXISTS teams (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id    BIGINT  NOT NULL,
+		name        TEXT    NOT NULL,
+		name_key    TEXT    NOT NULL,
+		created_by  BIGINT  NOT NULL,
+		created_at  INTEGER NOT NULL,
+		UNIQUE (guild_id, name_key)
+	);
+
+	CREATE TABLE IF NOT EXISTS team_members (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id   BIGINT  NOT NULL,
+		team_id    INTEGER NOT NULL REFERENCES teams (id),
+		user_id    BIGINT  NOT NULL,
+		joined_at  INTEGER NOT NULL,
+		left_at    INTEGER
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_team_members_open ON team_members (guild_id, user_id) WHERE left_at IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_team_members_team ON team_members (team_id, joined_at);
+`
+
+var (
+	ErrTeamExists = errors.New("a team with that name already exists")
+	ErrNoTeam     = errors.New("team not found")
+	ErrNotInTeam  = errors.New("not in a team")
+	ErrInTeam     = errors.New("already in that team")
+)
+
+type Team struct {
+	Id      int64
+	GuildId int64
+	Name    string
+	Members int // current members
+}
+
+// TeamStats aggregates the catches a team's members made while on the team
+type TeamStats struct {
+	Team
+	Catches int
+	Species int        // unique species
+	Best    fish.Catch // largest catch; UserId is who caught it
+}
+
+func teamKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// leaveTeamTx closes the user's open membership, returning the team they
+// left (zero if they weren't in one)
+func leaveTeamTx(ctx context.Context, tx *sql.Tx, guildId, userId int64, now time.Time) (int64, error) {
+	var teamId int64
+	err := tx.QueryRowContext(ctx, `
+		UPDATE team_members SET left_at = ?
+		WHERE guild_id = ? AND user_id = ? AND left_at IS NULL
+		RETURNING team_id
+	`, now.Unix(), guildId, userId).Scan(&teamId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return teamId, err
+}
+
+// CreateTeam founds a team with the user as its first member, moving them
+// out of their current team
+func (s *SQLiteStore) CreateTeam(ctx context.Context, guildId, userId int64, name string, now time.Time) (Team, error) {
+	if s == nil || s.db == nil {
+		return Team{}, errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Team{}, err
+	}
+	defer tx.Rollback()
+
+	name = strings.TrimSpace(name)
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO teams (guild_id, name, name_key, created_by, created_at) VALUES (?,?,?,?,?)
+		ON CONFLICT DO NOTHING
+	`, guildId, name, teamKey(name), userId, now.Unix())
+	if err != nil {
+		return Team{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return Team{}, ErrTeamExists
+	}
+	teamId, err := res.LastInsertId()
+	if err != nil {
+		return Team{}, err
+	}
+
+	if _, err := leaveTeamTx(ctx, tx, guildId, userId, now); err != nil {
+		return Team{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO team_members (guild_id, team_id, user_id, joined_at) VALUES (?,?,?,?)
+	`, guildId, teamId, userId, now.Unix()); err != nil {
+		return Team{}, err
+	}
+	return Team{Id: teamId, GuildId: guildId, Name: name, Members: 1}, tx.Commit()
+}
+
+// JoinTeam moves the user into the named team, leaving their current one.
+// Fails with ErrInTeam if they're already on it.
+func (s *SQLiteStore) JoinTeam(ctx context.Context, guildId, userId int64, name string, now time.Time) (Team, error) {
+	if s == nil || s.db == nil {
+		return Team{}, errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Team{}, err
+	}
+	defer tx.Rollback()
+
+	t := Team{GuildId: guildId}
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, name FROM teams WHERE guild_id = ? AND name_key = ?`,
+		guildId, teamKey(name),
+	).Scan(&t.Id, &t.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, ErrNoTeam
+	}
+	if err != nil {
+		return t, err
+	}
+
+	var current int64
+	err = tx.QueryRowContext(ctx,
+		`SELECT team_id FROM team_members WHERE guild_id = ? AND user_id = ? AND left_at IS NULL`,
+		guildId, userId,
+	).Scan(&current)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return t, err
+	}
+	if current == t.Id {
+		return t, ErrInTeam
+	}
+
+	if _, err := leaveTeamTx(ctx, tx, guildId, userId, now); err != nil {
+		return t, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO team_members (guild_id, team_id, user_id, joined_at) VALUES (?,?,?,?)
+	`, guildId, t.Id, userId, now.Unix()); err != nil {
+		return t, err
+	}
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM team_members WHERE team_id = ? AND left_at IS NULL`, t.Id,
+	).Scan(&t.Members); err != nil {
+		return t, err
+	}
+	return t, tx.Commit()
+}
+
+// LeaveTeam ends the user's current membership and returns the team they
+// left. Catches they made while on it keep counting for it.
+func (s *SQLiteStore) LeaveTeam(ctx context.Context, guildId, userId int64, now time.Time) (Team, error) {
+	if s == nil || s.db == nil {
+		return Team{}, errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Team{}, err
+	}
+	defer tx.Rollback()
+
+	teamId, err := leaveTeamTx(ctx, tx, guildId, userId, now)
+	if err != nil {
+		return Team{}, err
+	}
+	if teamId == 0 {
+		return Team{}, ErrNotInTeam
+	}
+	t := Team{Id: teamId, GuildId: guildId}
+	if err := tx.QueryRowContext(ctx, `
+		SELECT name, (SELECT COUNT(*) FROM team_members WHERE team_id = teams.id AND left_at IS NULL)
+		FROM teams WHERE id = ?
+	`, teamId).Scan(&t.Name, &t.Members); err != nil {
+		return t, err
+	}
+	return t, tx.Commit()
+}
+
+// Teams lists a guild's teams by name, with their current member counts
+func (s *SQLiteStore) Teams(ctx context.Context, guildId int64) ([]Team, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.id, t.name, COUNT(tm.id)
+		FROM teams t LEFT JOIN team_members tm ON tm.team_id = t.id AND tm.left_at IS NULL
+		WHERE t.guild_id = ?
+		GROUP BY t.id
+		ORDER BY t.name_key
+	`, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Team
+	for rows.Next() {
+		t := Team{GuildId: guildId}
+		if err := rows.Scan(&t.Id, &t.Name, &t.Members); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// CurrentTeam returns the team the user is on, or ErrNotInTeam
+func (s *SQLiteStore) CurrentTeam(ctx context.Context, guildId, userId int64) (Team, error) {
+	if s == nil || s.db == nil {
+		return Team{}, errors.New("store not initialized")
+	}
+
+	t := Team{GuildId: guildId}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT t.id, t.name, (SELECT COUNT(*) FROM team_members WHERE team_id = t.id AND left_at IS NULL)
+		FROM team_members tm JOIN teams t ON t.id = tm.team_id
+		WHERE tm.guild_id = ? AND tm.user_id = ? AND tm.left_at IS NULL
+	`, guildId, userId).Scan(&t.Id, &t.Name, &t.Members)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, ErrNotInTeam
+	}
+	return t, err
+}
+
+// TeamStats aggregates catches made since the given time by each team's
+// members while they were on the team, most catches first. Teams without
+// catches in the period are left out.
+func (s *SQLiteStore) TeamStats(ctx context.Context, guildId int64, since time.Time, limit int) ([]TeamStats, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	// SQLite takes the bare species_id/caught_by columns from the row that
+	// holds the MAX, which gives us each team's biggest catch for free
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.id, t.name,
+			(SELECT COUNT(*) FROM team_members WHERE team_id = t.id AND left_at IS NULL),
+			COUNT(c.id), COUNT(DISTINCT c.species_id),
+			MAX(c.size_tenths), c.species_id, c.caught_by, c.caught_at
+		FROM teams t
+		JOIN team_members tm ON tm.team_id = t.id
+		JOIN catches c ON c.guild_id = tm.guild_id AND c.caught_by = tm.user_id
+			AND c.caught_at >= tm.joined_at AND (tm.left_at IS NULL OR c.caught_at < tm.left_at)
+		WHERE t.guild_id = ? AND c.caught_at >= ?
+		GROUP BY t.id
+		ORDER BY COUNT(c.id) DESC, COUNT(DISTINCT c.species_id) DESC, MAX(c.size_tenths) DESC
+		LIMIT ?
+	`, guildId, since.Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []TeamStats
+	for rows.Next() {
+		var (
+			ts                     TeamStats
+			sizeTenths, caughtUnix int64
+			spid                   int
+		)
+		if err := rows.Scan(&ts.Id, &ts.Name, &ts.Members, &ts.Catches, &ts.Species,
+			&sizeTenths, &spid, &ts.Best.UserId, &caughtUnix); err != nil {
+			return nil, err
+		}
+		ts.GuildId = guildId
+		ts.Best.GuildId = guildId
+		ts.Best.SpeciesId = fish.SpeciesId(spid)
+		ts.Best.Size = float64(sizeTenths) / 10.0
+		ts.Best.CaughtAt = time.Unix(caughtUnix, 0).UTC()
+		out = append(out, ts)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,360 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/fish"
+)
+
+// A tournament is open from creation until it's ended, and only counts
+// catches made between starts_at and ends_at by users who joined, from when
+// they joined. Results are written when it ends; like auctions, announced is
+// tracked separately so a crash can't lose the results post. standings_msg_id
+// is the live standings message, 0 if there is none.
+const tournamentSchema = `
+	CREATE TABLE IF NOT EXISTS tournaments (
+		id                INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id          BIGINT  NOT NULL,
+		channel_id        BIGINT  NOT NULL,
+		creator_id        BIGINT  NOT NULL,
+		name              TEXT    NOT NULL,
+		species_id        INTEGER NOT NULL DEFAULT -1,
+		tag               TEXT    NOT NULL DEFAULT '',
+		metric            TEXT    NOT NULL,
+		prize             INTEGER NOT NULL DEFAULT 0,
+		starts_at         INTEGER NOT NULL,
+		ends_at           INTEGER NOT NULL,
+		status            TEXT    NOT NULL DEFAULT 'open',
+		standings_msg_id  BIGINT  NOT NULL DEFAULT 0,
+		announced         INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS idx_tournaments_status ON tournaments (status, ends_at);
+
+	CREATE TABLE IF NOT EXISTS tournament_entries (
+		tournament_id  INTEGER NOT NULL REFERENCES tournaments (id),
+		user_id        BIGINT  NOT NULL,
+		joined_at      INTEGER NOT NULL,
+		PRIMARY KEY (tournament_id, user_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS tournament_results (
+		tournament_id  INTEGER NOT NULL REFERENCES tournaments (id),
+		rank           INTEGER NOT NULL,
+		user_id        BIGINT  NOT NULL,
+		score          REAL    NOT NULL,
+		prize          INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (tournament_id, rank)
+	);
+`
+
+const (
+	TournamentOpen      = "open"
+	TournamentEnded     = "ended"
+	TournamentCancelled = "cancelled"
+)
+
+var (
+	ErrNoTournament     = errors.New("tournament not found")
+	ErrTournamentClosed = errors.New("tournament is over")
+	ErrAlreadyJoined    = errors.New("already joined")
+)
+
+type Tournament struct {
+	Id             int64
+	GuildId        int64
+	ChannelId      int64
+	CreatorId      int64
+	Name           string
+	Target         fish.TournamentTarget
+	Metric         string
+	Prize          int64 // pool paid from the rewards account, see fish.TournamentPrizes
+	StartsAt       time.Time
+	EndsAt         time.Time
+	Status         string
+	StandingsMsgId int64
+}
+
+// TournamentResult is a final placing
+type TournamentResult struct {
+	Rank   int
+	UserId int64
+	Score  float64
+	Prize  int64
+}
+
+const tournamentColumns = `
+	id, guild_id, channel_id, creator_id, name, species_id, tag, metric, prize,
+	starts_at, ends_at, status, standings_msg_id
+`
+
+func scanTournament(row interface{ Scan(...any) error }) (Tournament, error) {
+	var (
+		t                  Tournament
+		spid               int
+		startUnix, endUnix int64
+	)
+	err := row.Scan(&t.Id, &t.GuildId, &t.ChannelId, &t.CreatorId, &t.Name, &spid, &t.Target.Tag, &t.Metric, &t.Prize,
+		&startUnix, &endUnix, &t.Status, &t.StandingsMsgId)
+	if err != nil {
+		return t, err
+	}
+	t.Target.Species = fish.SpeciesId(spid)
+	t.StartsAt = time.Unix(startUnix, 0).UTC()
+	t.EndsAt = time.Unix(endUnix, 0).UTC()
+	return t, nil
+}
+
+func queryTournaments(ctx context.Context, q querier, where string, args ...any) ([]Tournament, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT `+tournamentColumns+` FROM tournaments
+		WHERE `+where+`
+		ORDER BY ends_at, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Tournament
+	for rows.Next() {
+		t, err := scanTournament(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteStore) CreateTournament(ctx context.Context, t Tournament) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO tournaments (guild_id, channel_id, creator_id, name, species_id, tag, metric, prize, starts_at, ends_at)
+		VALUES (?,?,?,?,?,?,?,?,?,?)
+	`, t.GuildId, t.ChannelId, t.CreatorId, t.Name, t.Target.Species, t.Target.Tag, t.Metric, t.Prize,
+		t.StartsAt.Unix(), t.EndsAt.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (s *SQLiteStore) Tournament(ctx context.Context, tournamentId int64) (Tournament, error) {
+	if s == nil || s.db == nil {
+		return Tournament{}, errors.New("store not initialized")
+	}
+
+	t, err := scanTournament(s.db.QueryRowContext(ctx,
+		`SELECT `+tournamentColumns+` FROM tournaments WHERE id = ?`, tournamentId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, ErrNoTournament
+	}
+	return t, err
+}
+
+// OpenTournaments lists a guild's tournaments that haven't ended, ending
+// soonest first. A guildId of 0 lists them for every guild.
+func (s *SQLiteStore) OpenTournaments(ctx context.Context, guildId int64) ([]Tournament, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return queryTournaments(ctx, s.db, `status = ? AND (? = 0 OR guild_id = ?)`, TournamentOpen, guildId, guildId)
+}
+
+// UnannouncedTournaments lists ended tournaments whose results haven't been
+// posted
+func (s *SQLiteStore) UnannouncedTournaments(ctx context.Context) ([]Tournament, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return queryTournaments(ctx, s.db, `status = ? AND announced = 0`, TournamentEnded)
+}
+
+func (s *SQLiteStore) MarkTournamentAnnounced(ctx context.Context, tournamentId int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE tournaments SET announced = 1 WHERE id = ?`, tournamentId)
+	return err
+}
+
+func (s *SQLiteStore) SetStandingsMessage(ctx context.Context, tournamentId, messageId int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE tournaments SET standings_msg_id = ? WHERE id = ?`, messageId, tournamentId)
+	return err
+}
+
+// JoinTournament enters the user into an open tournament. Only catches made
+// after joining count.
+func (s *SQLiteStore) JoinTournament(ctx context.Context, tournamentId, userId int64, now time.Time) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO tournament_entries (tournament_id, user_id, joined_at)
+		SELECT id, ?, ? FROM tournaments WHERE id = ? AND status = ? AND ends_at > ?
+		ON CONFLICT DO NOTHING
+	`, userId, now.Unix(), tournamentId, TournamentOpen, now.Unix())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return nil
+	}
+
+	// Work out why nothing was inserted
+	t, err := s.Tournament(ctx, tournamentId)
+	if err != nil {
+		return err
+	}
+	if t.Status != TournamentOpen || !now.Before(t.EndsAt) {
+		return ErrTournamentClosed
+	}
+	return ErrAlreadyJoined
+}
+
+// TournamentEntrants returns how many users have joined
+func (s *SQLiteStore) TournamentEntrants(ctx context.Context, tournamentId int64) (int, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM tournament_entries WHERE tournament_id = ?`, tournamentId,
+	).Scan(&n)
+	return n, err
+}
+
+// TournamentCatches returns the entrants' catches that fall in the
+// tournament window, whatever became of them since. Catch.UserId is the
+// entrant who caught the fish. Filtering by target is left to the caller.
+func (s *SQLiteStore) TournamentCatches(ctx context.Context, t Tournament) ([]fish.Catch, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, c.caught_by, c.species_id, c.size_tenths, c.caught_at
+		FROM tournament_entries e
+		JOIN catches c ON c.guild_id = ? AND c.caught_by = e.user_id
+		WHERE e.tournament_id = ?
+			AND c.caught_at >= MAX(e.joined_at, ?) AND c.caught_at < ?
+			AND (? < 0 OR c.species_id = ?)
+		ORDER BY c.caught_at, c.id
+	`, t.GuildId, t.Id, t.StartsAt.Unix(), t.EndsAt.Unix(), t.Target.Species, t.Target.Species)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []fish.Catch
+	for rows.Next() {
+		var (
+			c          fish.Catch
+			spid       int
+			sizeTenths int64
+			caughtUnix int64
+		)
+		if err := rows.Scan(&c.Id, &c.UserId, &spid, &sizeTenths, &caughtUnix); err != nil {
+			return nil, err
+		}
+		c.GuildId = t.GuildId
+		c.SpeciesId = fish.SpeciesId(spid)
+		c.Size = float64(sizeTenths) / 10.0
+		c.CaughtAt = time.Unix(caughtUnix, 0).UTC()
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// EndTournament closes an open tournament, records the results and pays out
+// prizes from the rewards account, all in one transaction. Ending before the
+// scheduled end moves ends_at up to now; cancelling records nothing. Ending
+// is idempotent: a tournament that is already over returns
+// ErrTournamentClosed.
+func (s *SQLiteStore) EndTournament(ctx context.Context, tournamentId int64, results []TournamentResult, cancel bool, now time.Time) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status := TournamentEnded
+	if cancel {
+		status = TournamentCancelled
+	}
+	var guildId int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE tournaments SET status = ?, announced = ?, ends_at = MIN(ends_at, ?)
+		WHERE id = ? AND status = ?
+		RETURNING guild_id
+	`, status, cancel, now.Unix(), tournamentId, TournamentOpen).Scan(&guildId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTournamentClosed
+	}
+	if err != nil {
+		return err
+	}
+	if cancel {
+		return tx.Commit()
+	}
+
+	for _, r := range results {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO tournament_results (tournament_id, rank, user_id, score, prize) VALUES (?,?,?,?,?)
+		`, tournamentId, r.Rank, r.UserId, r.Score, r.Prize); err != nil {
+			return err
+		}
+		if r.Prize <= 0 {
+			continue
+		}
+		if _, err := post(ctx, tx, guildId, "tournament", fmt.Sprintf("tournament #%d, place %d", tournamentId, r.Rank), now,
+			Posting{Account: AccountRewards, Amount: -r.Prize},
+			Posting{Account: UserAccount(r.UserId), Amount: r.Prize},
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// TournamentResults returns an ended tournament's placings, best first
+func (s *SQLiteStore) TournamentResults(ctx context.Context, tournamentId int64) ([]TournamentResult, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT rank, user_id, score, prize FROM tournament_results
+		WHERE tournament_id = ? ORDER BY rank
+	`, tournamentId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []TournamentResult
+	for rows.Next() {
+		var r TournamentResult
+		if err := rows.Scan(&r.Rank, &r.UserId, &r.Score, &r.Prize); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,383 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Trades are recorded permanently for moderation. Coins offered in a trade
+// are moved into the escrow account as soon as they're offered, and either
+// paid to the other side when the trade executes or refunded if it's
+// cancelled or expires. Catches stay with their owner until execution.
+const tradeSchema = `
+	CREATE TABLE IF NOT EXISTS trades (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id      BIGINT  NOT NULL,
+		channel_id    BIGINT  NOT NULL,
+		initiator_id  BIGINT  NOT NULL,
+		partner_id    BIGINT  NOT NULL,
+		status        TEXT    NOT NULL DEFAULT 'open',
+		created_at    INTEGER NOT NULL,
+		closed_at     INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_trades_status ON trades (status);
+
+	CREATE TABLE IF NOT EXISTS trade_items (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		trade_id   INTEGER NOT NULL REFERENCES trades (id),
+		from_user  BIGINT  NOT NULL,
+		catch_id   INTEGER,
+		coins      INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS idx_trade_items_trade ON trade_items (trade_id);
+`
+
+const (
+	TradeOpen      = "open"
+	TradeExecuted  = "executed"
+	TradeCancelled = "cancelled"
+	TradeExpired   = "expired"
+)
+
+const AccountEscrow = "system:escrow"
+
+var (
+	ErrTradeClosed   = errors.New("trade is no longer open")
+	ErrCatchNotOwned = errors.New("catch is not owned by the user or already sold")
+	ErrTradeItemGone = errors.New("an offered catch is no longer available")
+	errTradeNotFound = errors.New("trade not found")
+)
+
+type Trade struct {
+	Id          int64
+	GuildId     int64
+	ChannelId   int64
+	InitiatorId int64
+	PartnerId   int64
+	Status      string
+}
+
+func (s *SQLiteStore) CreateTrade(ctx context.Context, guildId, channelId, initiatorId, partnerId int64) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO trades (guild_id, channel_id, initiator_id, partner_id, created_at)
+		VALUES (?,?,?,?,?)
+	`, guildId, channelId, initiatorId, partnerId, time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func loadTradeTx(ctx context.Context, q querier, tradeId int64) (Trade, error) {
+	var t Trade
+	err := q.QueryRowContext(ctx, `
+		SELECT id, guild_id, channel_id, initiator_id, partner_id, status
+		FROM trades WHERE id = ?
+	`, tradeId).Scan(&t.Id, &t.GuildId, &t.ChannelId, &t.InitiatorId, &t.PartnerId, &t.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, errTradeNotFound
+	}
+	return t, err
+}
+
+// AddTradeCatch offers one of the user's unsold catches
+func (s *SQLiteStore) AddTradeCatch(ctx context.Context, tradeId, userId, catchId int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t, err := loadTradeTx(ctx, tx, tradeId)
+	if err != nil {
+		return err
+	}
+	if t.Status != TradeOpen {
+		return ErrTradeClosed
+	}
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM catches
+		WHERE id = ? AND guild_id = ? AND user_id = ? AND sold_at IS NULL AND released_at IS NULL AND `+notAuctioned,
+		catchId, t.GuildId, userId,
+	).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCatchNotOwned
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM trade_items WHERE trade_id = ? AND catch_id = ?
+	`, tradeId, catchId).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil // already offered
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO trade_items (trade_id, from_user, catch_id) VALUES (?,?,?)
+	`, tradeId, userId, catchId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EscrowCoins moves coins from the user into escrow for the trade
+func (s *SQLiteStore) EscrowCoins(ctx context.Context, tradeId, userId, amount int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("invalid amount %d", amount)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t, err := loadTradeTx(ctx, tx, tradeId)
+	if err != nil {
+		return err
+	}
+	if t.Status != TradeOpen {
+		return ErrTradeClosed
+	}
+
+	if _, err := post(ctx, tx, t.GuildId, "trade_escrow", fmt.Sprintf("trade #%d", tradeId), time.Now(),
+		Posting{Account: UserAccount(userId), Amount: -amount},
+		Posting{Account: AccountEscrow, Amount: amount},
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO trade_items (trade_id, from_user, coins) VALUES (?,?,?)
+	`, tradeId, userId, amount); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ExecuteTrade swaps every offered catch and pays out escrowed coins in one
+// transaction. If any catch changed hands or was sold in the meantime the
+// whole trade fails with ErrTradeItemGone and nothing moves.
+func (s *SQLiteStore) ExecuteTrade(ctx context.Context, tradeId int64) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t, err := loadTradeTx(ctx, tx, tradeId)
+	if err != nil {
+		return err
+	}
+	if t.Status != TradeOpen {
+		return ErrTradeClosed
+	}
+
+	other := func(userId int64) int64 {
+		if userId == t.InitiatorId {
+			return t.PartnerId
+		}
+		return t.InitiatorId
+	}
+
+	items, err := tradeItemsTx(ctx, tx, tradeId)
+	if err != nil {
+		return err
+	}
+
+	coinsTo := map[int64]int64{}
+	for _, it := range items {
+		if it.Coins > 0 {
+			coinsTo[other(it.FromUser)] += it.Coins
+			continue
+		}
+		res, err := tx.ExecContext(ctx, `
+			UPDATE catches SET user_id = ?
+			WHERE id = ? AND guild_id = ? AND user_id = ? AND sold_at IS NULL AND released_at IS NULL AND `+notAuctioned,
+			other(it.FromUser), it.CatchId, t.GuildId, it.FromUser)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n != 1 {
+			return ErrTradeItemGone
+		}
+	}
+
+	now := time.Now()
+	var postings []Posting
+	var total int64
+	for userId, amount := range coinsTo {
+		postings = append(postings, Posting{Account: UserAccount(userId), Amount: amount})
+		total += amount
+	}
+	if total > 0 {
+		postings = append(postings, Posting{Account: AccountEscrow, Amount: -total})
+		if _, err := post(ctx, tx, t.GuildId, "trade", fmt.Sprintf("trade #%d", tradeId), now, postings...); err != nil {
+			return err
+		}
+	}
+
+	if err := closeTradeTx(ctx, tx, tradeId, TradeExecuted, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CloseTrade cancels or expires an open trade and refunds escrowed coins
+func (s *SQLiteStore) CloseTrade(ctx context.Context, tradeId int64, status string) error {
+	if s == nil || s.db == nil {
+		return errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := refundTradeTx(ctx, tx, tradeId, status); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ExpireOpenTrades closes every trade still open that was created before
+// cutoff, e.g. after a restart lost the in-memory sessions, refunding escrow.
+// Returns how many were expired.
+func (s *SQLiteStore) ExpireOpenTrades(ctx context.Context, cutoff time.Time) (int, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM trades WHERE status = ? AND created_at < ?`, TradeOpen, cutoff.Unix())
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, id := range ids {
+		err := s.CloseTrade(ctx, id, TradeExpired)
+		if errors.Is(err, ErrTradeClosed) {
+			continue // closed by its own session in the meantime
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func refundTradeTx(ctx context.Context, tx *sql.Tx, tradeId int64, status string) error {
+	t, err := loadTradeTx(ctx, tx, tradeId)
+	if err != nil {
+		return err
+	}
+	if t.Status != TradeOpen {
+		return ErrTradeClosed
+	}
+
+	items, err := tradeItemsTx(ctx, tx, tradeId)
+	if err != nil {
+		return err
+	}
+
+	refunds := map[int64]int64{}
+	for _, it := range items {
+		refunds[it.FromUser] += it.Coins
+	}
+
+	now := time.Now()
+	for userId, amount := range refunds {
+		if amount == 0 {
+			continue
+		}
+		if _, err := post(ctx, tx, t.GuildId, "trade_refund", fmt.Sprintf("trade #%d %s", tradeId, status), now,
+			Posting{Account: AccountEscrow, Amount: -amount},
+			Posting{Account: UserAccount(userId), Amount: amount},
+		); err != nil {
+			return err
+		}
+	}
+
+	return closeTradeTx(ctx, tx, tradeId, status, now)
+}
+
+func closeTradeTx(ctx context.Context, tx *sql.Tx, tradeId int64, status string, at time.Time) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE trades SET status = ?, closed_at = ? WHERE id = ?`,
+		status, at.Unix(), tradeId,
+	)
+	return err
+}
+
+type TradeItem struct {
+	FromUser int64
+	CatchId  int64 // 0 for coins
+	Coins    int64
+}
+
+func (s *SQLiteStore) TradeItems(ctx context.Context, tradeId int64) ([]TradeItem, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return tradeItemsTx(ctx, s.db, tradeId)
+}
+
+func tradeItemsTx(ctx context.Context, q querier, tradeId int64) ([]TradeItem, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT from_user, COALESCE(catch_id, 0), coins
+		FROM trade_items WHERE trade_id = ?
+		ORDER BY id
+	`, tradeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []TradeItem
+	for rows.Next() {
+		var it TradeItem
+		if err := rows.Scan(&it.FromUser, &it.CatchId, &it.Coins); err != nil {
+			return nil, err
+		}
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
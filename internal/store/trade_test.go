@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExpireOpenTradesByAge(t *testing.T) {
+	st := openTestStore(t)
+	ctx := context.Background()
+
+	old, err := st.CreateTrade(ctx, 1, 10, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.db.ExecContext(ctx, `UPDATE trades SET created_at = ? WHERE id = ?`, time.Now().Add(-time.Hour).Unix(), old); err != nil {
+		t.Fatal(err)
+	}
+	// Another shard's trade, still in progress
+	young, err := st.CreateTrade(ctx, 4, 40, 5, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := st.ExpireOpenTrades(ctx, time.Now().Add(-10*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expired %d trades, want 1", n)
+	}
+	for id, want := range map[int64]string{old: TradeExpired, young: TradeOpen} {
+		var status string
+		if err := st.db.QueryRowContext(ctx, `SELECT status FROM trades WHERE id = ?`, id).Scan(&status); err != nil {
+			t.Fatal(err)
+		}
+		if status != want {
+			t.Errorf("trade %d is %s, want %s", id, status, want)
+		}
+	}
+}
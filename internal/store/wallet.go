@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Every balance change is a ledger transaction whose entries sum to zero.
+// User accounts are "user:<id>"; everything else is a system account that is
+// allowed to go negative (e.g. the market "prints" the coins it pays out).
+// wallets is a materialized balance per user, updated in the same SQL
+// transaction as the ledger so the two can never drift.
+const walletSchema = `
+	CREATE TABLE IF NOT EXISTS wallets (
+		guild_id  BIGINT  NOT NULL,
+		user_id   BIGINT  NOT NULL,
+		balance   INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (guild_id, user_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS ledger_txns (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id    BIGINT  NOT NULL,
+		kind        TEXT    NOT NULL,
+		memo        TEXT    NOT NULL DEFAULT '',
+		created_at  INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS ledger_entries (
+		id       INTEGER PRIMARY KEY AUTOINCREMENT,
+		txn_id   INTEGER NOT NULL REFERENCES ledger_txns (id),
+		account  TEXT    NOT NULL,
+		amount   INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_ledger_entries_txn
+		ON ledger_entries (txn_id);
+	CREATE INDEX IF NOT EXISTS idx_ledger_entries_account
+		ON ledger_entries (account, txn_id);
+`
+
+// System accounts
+const (
+	AccountMarket  = "system:market"
+	AccountRewards = "system:rewards"
+)
+
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// Posting is one side of a ledger transaction. Positive amounts credit the
+// account, negative amounts debit it.
+type Posting struct {
+	Account string
+	Amount  int64
+}
+
+func UserAccount(userId int64) string {
+	return "user:" + strconv.FormatInt(userId, 10)
+}
+
+func userFromAccount(account string) (int64, bool) {
+	rest, ok := strings.CutPrefix(account, "user:")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(rest, 10, 64)
+	return id, err == nil
+}
+
+func (s *SQLiteStore) Balance(ctx context.Context, guildId, userId int64) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+	return balanceTx(ctx, s.db, guildId, userId)
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func balanceTx(ctx context.Context, q querier, guildId, userId int64) (int64, error) {
+	var bal int64
+	err := q.QueryRowContext(ctx,
+		`SELECT balance FROM wallets WHERE guild_id = ? AND user_id = ?`,
+		guildId, userId,
+	).Scan(&bal)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return bal, err
+}
+
+// post records a balanced ledger transaction inside tx and applies it to the
+// wallets of any user accounts involved. It fails with ErrInsufficientFunds
+// if a user balance would go negative.
+func post(ctx context.Context, tx *sql.Tx, guildId int64, kind, memo string, at time.Time, postings ...Posting) (int64, error) {
+	var sum int64
+	for _, p := range postings {
+		sum += p.Amount
+	}
+	if sum != 0 {
+		return 0, fmt.Errorf("unbalanced %s transaction: entries sum to %d", kind, sum)
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO ledger_txns (guild_id, kind, memo, created_at) VALUES (?,?,?,?)`,
+		guildId, kind, memo, at.Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	txnId, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range postings {
+		if p.Amount == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO ledger_entries (txn_id, account, amount) VALUES (?,?,?)`,
+			txnId, p.Account, p.Amount,
+		); err != nil {
+			return 0, err
+		}
+
+		userId, ok := userFromAccount(p.Account)
+		if !ok {
+			continue
+		}
+		var bal int64
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO wallets (guild_id, user_id, balance) VALUES (?,?,?)
+			ON CONFLICT (guild_id, user_id) DO UPDATE SET balance = balance + excluded.balance
+			RETURNING balance
+		`, guildId, userId, p.Amount).Scan(&bal)
+		if err != nil {
+			return 0, err
+		}
+		if bal < 0 {
+			return 0, ErrInsufficientFunds
+		}
+	}
+
+	return txnId, nil
+}
+
+// Transfer posts a single balanced transaction outside of any other work,
+// e.g. a reward paid by a system account.
+func (s *SQLiteStore) Transfer(ctx context.Context, guildId int64, kind, memo string, postings ...Posting) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	txnId, err := post(ctx, tx, guildId, kind, memo, time.Now(), postings...)
+	if err != nil {
+		return 0, err
+	}
+	return txnId, tx.Commit()
+}
@@ -0,0 +1,250 @@
+package view
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/fish"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// CatchCardName is the attachment name embeds use to show the card
+// ("attachment://" + CatchCardName)
+const CatchCardName = "catch.png"
+
+// Card layout, in pixels
+const (
+	cardWidth  = 640
+	cardHeight = 320
+	cardBorder = 12
+	cardPad    = 24
+	cardArt    = cardHeight - 2*cardBorder - 2*cardPad
+)
+
+var (
+	cardBackground = color.RGBA{0x23, 0x27, 0x2a, 0xff}
+	cardPanel      = color.RGBA{0x2c, 0x2f, 0x33, 0xff}
+	cardText       = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	cardMuted      = color.RGBA{0x99, 0xaa, 0xb5, 0xff}
+)
+
+// CatchCard is everything drawn on a catch card
+type CatchCard struct {
+	Species   string
+	Art       image.Image // nil draws a plain fish shape in the tier color
+	Size      float64     // cm
+	SizeClass fish.SizeClass
+	Tier      fish.RarityTier
+	Angler    string    // "" leaves out the "Caught by" line
+	CaughtAt  time.Time // the date is shown in CaughtAt's location
+}
+
+// Species art is compiled in as art/<species key>.png, so rendering never
+// needs anything fetched
+//
+//go:embed art/*.png
+var artFS embed.FS
+
+var (
+	artMu    sync.Mutex
+	artCache = map[string]image.Image{}
+)
+
+// SpeciesArt returns the bundled art for a species key, or nil if it has none
+func SpeciesArt(key string) image.Image {
+	artMu.Lock()
+	defer artMu.Unlock()
+	if img, ok := artCache[key]; ok {
+		return img
+	}
+
+	var img image.Image
+	if raw, err := artFS.ReadFile("art/" + key + ".png"); err == nil {
+		if img, err = png.Decode(bytes.NewReader(raw)); err != nil {
+			log.Printf("failed to decode art for %s: %v", key, err)
+			img = nil
+		}
+	}
+	artCache[key] = img
+	return img
+}
+
+// The Go fonts are compiled in, so rendering never touches the network or
+// the filesystem
+var (
+	fontsOnce sync.Once
+	fontsErr  error
+	regular   *opentype.Font
+	bold      *opentype.Font
+)
+
+func loadFonts() error {
+	fontsOnce.Do(func() {
+		if regular, fontsErr = opentype.Parse(goregular.TTF); fontsErr != nil {
+			return
+		}
+		bold, fontsErr = opentype.Parse(gobold.TTF)
+	})
+	return fontsErr
+}
+
+func face(f *opentype.Font, size float64) (font.Face, error) {
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+// RenderCatchCard draws the card as a PNG
+func RenderCatchCard(c CatchCard) ([]byte, error) {
+	if err := loadFonts(); err != nil {
+		return nil, fmt.Errorf("failed to load fonts: %w", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	tierColor := rgb(fish.ColorForTier(c.Tier))
+
+	// Tier-colored border around the whole card
+	fill(img, img.Bounds(), tierColor)
+	fill(img, img.Bounds().Inset(cardBorder), cardBackground)
+
+	// Art on the left, in a square panel
+	artRect := image.Rect(cardBorder+cardPad, cardBorder+cardPad, cardBorder+cardPad+cardArt, cardBorder+cardPad+cardArt)
+	fill(img, artRect, cardPanel)
+	if c.Art != nil {
+		drawArt(img, artRect.Inset(12), c.Art)
+	} else {
+		drawFish(img, artRect.Inset(24), tierColor)
+	}
+
+	// Text on the right
+	textX := artRect.Max.X + cardPad
+	textW := cardWidth - cardBorder - cardPad - textX
+	lines := []struct {
+		font  *opentype.Font
+		size  float64
+		color color.Color
+		text  string
+		gap   int
+	}{
+		{bold, 34, cardText, c.Species, 0},
+		{bold, 28, cardText, fmt.Sprintf("%.1f cm", c.Size), 16},
+		{regular, 22, tierColor, fmt.Sprintf("%s · %s", title(c.SizeClass.String()), c.Tier), 8},
+		{regular, 20, cardMuted, "Caught by " + c.Angler, 40},
+		{regular, 18, cardMuted, c.CaughtAt.Format("2 January 2006"), 8},
+	}
+	if c.Angler == "" {
+		// The date moves up to where the angler would be
+		lines[4].gap = lines[3].gap
+		lines = append(lines[:3], lines[4])
+	}
+
+	y := artRect.Min.Y
+	for _, l := range lines {
+		f, err := face(l.font, l.size)
+		if err != nil {
+			return nil, err
+		}
+		y += l.gap + f.Metrics().Ascent.Ceil()
+		d := &font.Drawer{Dst: img, Src: image.NewUniform(l.color), Face: f, Dot: fixed.P(textX, y)}
+		d.DrawString(fitText(f, l.text, textW))
+		y += f.Metrics().Descent.Ceil()
+		f.Close()
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func rgb(c int) color.RGBA {
+	return color.RGBA{uint8(c >> 16), uint8(c >> 8), uint8(c), 0xff}
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	return string(s[0]-'a'+'A') + s[1:]
+}
+
+// fitText shortens s with an ellipsis until it fits in width pixels
+func fitText(f font.Face, s string, width int) string {
+	limit := fixed.I(width)
+	if font.MeasureString(f, s) <= limit {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && font.MeasureString(f, string(r)+"…") > limit {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
+
+// drawArt scales art to fit r, keeping its aspect ratio, and centers it
+func drawArt(img *image.RGBA, r image.Rectangle, art image.Image) {
+	src := art.Bounds()
+	if src.Empty() {
+		return
+	}
+	w, h := r.Dx(), src.Dy()*r.Dx()/src.Dx()
+	if h > r.Dy() {
+		w, h = src.Dx()*r.Dy()/src.Dy(), r.Dy()
+	}
+	at := r.Min.Add(image.Pt((r.Dx()-w)/2, (r.Dy()-h)/2))
+	draw.CatmullRom.Scale(img, image.Rectangle{at, at.Add(image.Pt(w, h))}, art, src, draw.Over, nil)
+}
+
+// drawFish draws a simple fish silhouette (an oval body and a tail) filling r
+func drawFish(img *image.RGBA, r image.Rectangle, c color.Color) {
+	w, h := float64(r.Dx()), float64(r.Dy())
+	cx, cy := float64(r.Min.X)+w*0.42, float64(r.Min.Y)+h/2
+	rx, ry := w*0.36, h*0.22
+	tailX := cx + rx*0.85
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			fx, fy := float64(x)+0.5, float64(y)+0.5
+			dx, dy := (fx-cx)/rx, (fy-cy)/ry
+			body := dx*dx+dy*dy <= 1
+			// The tail is a triangle opening away from the body
+			tail := fx >= tailX && fx <= float64(r.Max.X) && abs(fy-cy) <= (fx-tailX)*0.8
+			if body || tail {
+				img.Set(x, y, c)
+			}
+		}
+	}
+
+	// Eye
+	ex, ey, er := cx-rx*0.55, cy-ry*0.25, ry*0.14
+	for y := int(ey - er); y <= int(ey+er); y++ {
+		for x := int(ex - er); x <= int(ex+er); x++ {
+			dx, dy := float64(x)-ex, float64(y)-ey
+			if dx*dx+dy*dy <= er*er {
+				img.Set(x, y, cardPanel)
+			}
+		}
+	}
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,93 @@
+package view
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faideww/chat-fishing/internal/fish"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestRenderCatchCard(t *testing.T) {
+	caught := time.Date(2024, time.June, 21, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		golden string
+		card   CatchCard
+	}{
+		{"card_common.png", CatchCard{Species: "Bluegill", Size: 14.2, SizeClass: fish.SizeSmall, Tier: fish.TierCommon, Angler: "Ahab", CaughtAt: caught}},
+		{"card_mythic.png", CatchCard{Species: "Coelacanth", Size: 187.4, SizeClass: fish.SizeEnormous, Tier: fish.TierMythic, Angler: "Captain Ahab", CaughtAt: caught}},
+		{"card_long_names.png", CatchCard{Species: "Atlantic Bluefin Tuna of Unusual Size", Size: 301, SizeClass: fish.SizeHuge, Tier: fish.TierLegendary, Angler: "Someone With A Very Long Display Name", CaughtAt: caught}},
+		// Bundled art, with the date in a timezone where it's already the next day
+		{"card_art.png", CatchCard{Species: "Bluegill", Art: SpeciesArt("bluegill"), Size: 19.8, SizeClass: fish.SizeBig, Tier: fish.TierCommon, Angler: "Ahab", CaughtAt: caught.In(time.FixedZone("NZST", 12*60*60))}},
+		{"card_no_angler.png", CatchCard{Species: "Rainbow Trout", Size: 61.5, SizeClass: fish.SizeBig, Tier: fish.TierRare, CaughtAt: caught}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			raw, err := RenderCatchCard(tt.card)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, raw, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			// Compare pixels rather than bytes, so a different PNG encoder
+			// doesn't fail the test
+			if x, y, ok := samePixels(t, decode(t, raw), decode(t, want)); !ok {
+				t.Errorf("card differs from %s at (%d, %d); if the change is intended, run go test -update", path, x, y)
+			}
+		})
+	}
+}
+
+func TestSpeciesArt(t *testing.T) {
+	if SpeciesArt("bluegill") == nil {
+		t.Error("no art for bluegill")
+	}
+	if SpeciesArt("no_such_fish") != nil {
+		t.Error("art for a species that has none")
+	}
+}
+
+func decode(t *testing.T, raw []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// samePixels returns the first pixel where a and b differ
+func samePixels(t *testing.T, a, b image.Image) (int, int, bool) {
+	t.Helper()
+	if a.Bounds() != b.Bounds() {
+		t.Fatalf("card is %v, golden is %v", a.Bounds(), b.Bounds())
+	}
+	r := a.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			ar, ag, ab, aa := a.At(x, y).RGBA()
+			br, bg, bb, ba := b.At(x, y).RGBA()
+			if ar != br || ag != bg || ab != bb || aa != ba {
+				return x, y, false
+			}
+		}
+	}
+	return 0, 0, true
+}
@@ -0,0 +1,63 @@
+package view
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// TournamentView is a tournament's standings, or its final results
+type TournamentView struct {
+	Id       int64
+	Name     string
+	Metric   string // "largest", "most" or "percentile"
+	Target   string // which catches count
+	Upcoming bool   // hasn't started yet
+	StartsAt int64  // unix times
+	EndsAt   int64
+	Prize    int64 // pool
+	Entrants int
+	Rows     []StandingRow
+}
+
+// StandingRow is one placed angler
+type StandingRow struct {
+	Place   int // from 1
+	UserId  int64
+	Score   string
+	Size    float64 // best catch, in standings
+	Species string
+	Prize   int64 // won, in results
+}
+
+// TournamentEmbed lays out a tournament's current standings
+func TournamentEmbed(v TournamentView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("tournament", o, v, 0xf39c12)
+}
+
+// TournamentResultsEmbed lays out a finished tournament
+func TournamentResultsEmbed(v TournamentView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("tournament.results", o, v, 0xf39c12)
+}
+
+// TeamBoardView ranks a guild's teams
+type TeamBoardView struct {
+	Period string // "day", "week" or "month", "" for all time
+	Rows   []TeamRow
+}
+
+// TeamRow is one team and its biggest catch
+type TeamRow struct {
+	Pos         int
+	Name        string
+	Catches     int
+	Species     int
+	Members     int
+	BestSize    float64
+	BestSpecies string
+	BestClass   string
+	BestUserId  int64
+}
+
+// TeamBoardEmbed lays out the team leaderboard
+func TeamBoardEmbed(v TeamBoardView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("team", o, v, 0xf1c40f)
+}
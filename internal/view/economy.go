@@ -0,0 +1,261 @@
+package view
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DailyView is a claimed daily reward
+type DailyView struct {
+	Coins       int64
+	Bait        string
+	BaitQty     int // 0 when the reward has no bait
+	Streak      int
+	MaxStreak   bool  // Streak already earns the biggest reward
+	StreakBonus int64 // extra coins per streak day
+	Balance     int64
+	Timezone    string
+}
+
+// DailyEmbed lays out a claimed daily reward
+func DailyEmbed(v DailyView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("daily", o, v, 0xe67e22)
+}
+
+// SellView is a completed sale
+type SellView struct {
+	CatchId int64 // the catch sold by number, 0 when selling by filter
+	Count   int
+	Total   int64
+	Balance int64
+	Quests  []QuestView
+}
+
+// SellEmbed lays out a completed sale
+func SellEmbed(v SellView, o Overrides) *discordgo.MessageEmbed {
+	e := textEmbed("sell", o, v, 0x2ecc71)
+	e.Fields = QuestFields(v.Quests, o)
+	return e
+}
+
+// ShopView is the tackle shop as one user sees it
+type ShopView struct {
+	Balance int64
+	Slots   []ShopSlot
+	Bait    []ShopBait
+}
+
+// ShopSlot is the gear for one loadout slot
+type ShopSlot struct {
+	Slot  string // "rod", "reel" or "line"
+	Items []ShopGear
+}
+
+// ShopGear is one piece of gear
+type ShopGear struct {
+	Name        string
+	Key         string
+	Description string
+	Price       int64
+	Equipped    bool
+	Owned       bool
+}
+
+// ShopBait is one kind of bait
+type ShopBait struct {
+	Name        string
+	Key         string
+	Description string
+	Price       int64 // per pack
+	Pack        int
+	Have        int
+}
+
+// ShopEmbed lays out the tackle shop. Empty slots are left out.
+func ShopEmbed(v ShopView, o Overrides) *discordgo.MessageEmbed {
+	e := textEmbed("shop", o, v, 0xe67e22)
+	for _, slot := range v.Slots {
+		if len(slot.Items) > 0 {
+			e.Fields = append(e.Fields, part("shop.slot", o, slot))
+		}
+	}
+	if len(v.Bait) > 0 {
+		e.Fields = append(e.Fields, part("shop.bait", o, v))
+	}
+	return e
+}
+
+// MarketView is the fish market's recent prices
+type MarketView struct {
+	Global bool // prices are shared by every server
+	Days   int
+	Rows   []MarketRow
+	AtBase int // species left out for trading at their base price
+}
+
+// MarketRow is one species' price history, oldest first
+type MarketRow struct {
+	Species string
+	Price   int64
+	Prev    int64 // the price the day before
+	Base    int64
+	History []int64
+}
+
+// Sparkline draws the price history as unicode bars scaled between its
+// lowest and highest price
+func (r MarketRow) Sparkline() string {
+	const bars = "▁▂▃▄▅▆▇█"
+	runes := []rune(bars)
+	if len(r.History) == 0 {
+		return ""
+	}
+	lo, hi := r.History[0], r.History[0]
+	for _, v := range r.History {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+
+	var b strings.Builder
+	for _, v := range r.History {
+		idx := len(runes) - 1
+		if hi > lo {
+			idx = int(float64(v-lo) / float64(hi-lo) * float64(len(runes)-1))
+		}
+		b.WriteRune(runes[idx])
+	}
+	return b.String()
+}
+
+// marketRowsLength is roughly where the market stops listing rows, leaving
+// room for the summary under them
+const marketRowsLength = 3500
+
+// MarketEmbed lays out the fish market. Rows past what fits are counted
+// with the species at their base price.
+func MarketEmbed(v MarketView, o Overrides) *discordgo.MessageEmbed {
+	var desc strings.Builder
+	for _, r := range v.Rows {
+		if desc.Len() > marketRowsLength {
+			v.AtBase++
+			continue
+		}
+		desc.WriteString(render("market.row", o, r))
+		desc.WriteString("\n")
+	}
+	if v.AtBase > 0 {
+		desc.WriteString(render("market.rest", o, v))
+	}
+
+	e := textEmbed("market", o, v, 0x2ecc71)
+	e.Description = clip(desc.String(), maxDescription)
+	return e
+}
+
+// InventoryView is one page of a user's unsold catches
+type InventoryView struct {
+	Species string // the species filtered to, "" for all
+	Rows    []InventoryRow
+	Page    int // from 1
+	Pages   int
+	Count   int   // catches on every page
+	Total   int64 // their combined value
+}
+
+// InventoryRow is one unsold catch
+type InventoryRow struct {
+	Id        int64
+	Species   string
+	Size      float64
+	SizeClass string
+	Tier      string
+	Value     int64
+}
+
+// InventoryEmbed lays out a page of the inventory
+func InventoryEmbed(v InventoryView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("inventory", o, v, 0x3498db)
+}
+
+// AuctionView is one auction
+type AuctionView struct {
+	Id          int64
+	SellerId    int64
+	Species     string
+	Size        float64
+	Tier        string
+	Color       int
+	ThumbURL    string
+	MinBid      int64
+	TopBid      int64
+	TopBidderId int64 // 0 while there are no bids
+	EndsAt      int64 // unix time
+	Sold        bool  // closed with a winner
+}
+
+// AuctionsView is the open auctions in a guild
+type AuctionsView struct {
+	Rows []AuctionView
+	More int // open auctions left off the list
+}
+
+func auctionEmbed(layout string, v AuctionView, o Overrides) *discordgo.MessageEmbed {
+	e := textEmbed(layout, o, v, v.Color)
+	if v.ThumbURL != "" {
+		e.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: v.ThumbURL}
+	}
+	return e
+}
+
+// AuctionListEmbed lays out the auction house
+func AuctionListEmbed(v AuctionsView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("auction.list", o, v, 0x9b59b6)
+}
+
+// AuctionNewEmbed announces a new auction
+func AuctionNewEmbed(v AuctionView, o Overrides) *discordgo.MessageEmbed {
+	return auctionEmbed("auction.new", v, o)
+}
+
+// AuctionClosedEmbed announces how an auction ended
+func AuctionClosedEmbed(v AuctionView, o Overrides) *discordgo.MessageEmbed {
+	return auctionEmbed("auction.closed", v, o)
+}
+
+// TradeView is a trade between two users
+type TradeView struct {
+	Id          int64
+	Initiator   string // user ids
+	Partner     string
+	Status      string // "", "complete", "stale", "failed", "cancelled" or "expired"
+	CancelledBy string // display name, for "cancelled"
+	Offers      []TradeOffer
+}
+
+// TradeOffer is what one side of a trade puts in
+type TradeOffer struct {
+	Name      string
+	Confirmed bool
+	Items     []TradeItem
+	Coins     int64
+}
+
+// TradeItem is an offered catch
+type TradeItem struct {
+	CatchId int64
+	Gone    bool // sold or traded away since it was offered
+	Species string
+	Size    float64
+	Value   int64
+}
+
+// TradeEmbed lays out a trade and both offers
+func TradeEmbed(v TradeView, o Overrides) *discordgo.MessageEmbed {
+	e := textEmbed("trade", o, v, 0x2ecc71)
+	for _, offer := range v.Offers {
+		f := part("trade.offer", o, offer)
+		f.Inline = true
+		e.Fields = append(e.Fields, f)
+	}
+	return e
+}
@@ -1 +1,241 @@
 package view
+
+import (
+	"embed"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Message layouts are text templates, one file per layout under templates/.
+// Each layout is made of named parts ("catch.title", "leaderboard.row", ...)
+// and a guild can override any part with its own template text. Views only
+// decide what goes where in the embed; all wording lives in the templates.
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+var defaults = template.Must(template.ParseFS(templateFiles, "templates/*.tmpl"))
+
+// Overrides maps a template name to a guild's replacement text
+type Overrides map[string]string
+
+// TemplateNames lists every part a guild can override
+func TemplateNames() []string {
+	var names []string
+	for _, t := range defaults.Templates() {
+		// Skip the files themselves, which are templates named "<layout>.tmpl"
+		if !strings.HasSuffix(t.Name(), ".tmpl") {
+			names = append(names, t.Name())
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ValidateTemplate checks that text parses and renders against sample data
+// for the named part, and returns the rendered sample as a preview
+func ValidateTemplate(name, text string) (string, error) {
+	sample, ok := sampleFor(name)
+	if !ok || defaults.Lookup(name) == nil {
+		return "", fmt.Errorf("unknown template %q", name)
+	}
+	return executeOverride(name, text, sample)
+}
+
+// render executes the named part, using the guild's override if it has a
+// working one. A broken override, or one that hits the override limits,
+// falls back to the default rather than losing the message.
+func render(name string, o Overrides, data any) string {
+	if text, ok := o[name]; ok {
+		out, err := executeOverride(name, text, data)
+		if err == nil {
+			return out
+		}
+		log.Printf("template override %s failed, using the default: %v", name, err)
+	}
+
+	var b strings.Builder
+	if err := defaults.ExecuteTemplate(&b, name, data); err != nil {
+		log.Printf("template %s failed: %v", name, err)
+	}
+	return b.String()
+}
+
+// Discord rejects embeds with oversized parts, which overrides could still
+// produce within maxOverrideOutput; parts are clipped as the last step
+const (
+	maxTitle       = 256
+	maxDescription = 4096
+	maxFieldName   = 256
+	maxFieldValue  = 1024
+	maxFooter      = 2048
+)
+
+func clip(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+func field(name, value string) *discordgo.MessageEmbedField {
+	return &discordgo.MessageEmbedField{Name: clip(name, maxFieldName), Value: clip(value, maxFieldValue)}
+}
+
+// part renders "<prefix>.name" and "<prefix>.value" as a field
+func part(prefix string, o Overrides, data any) *discordgo.MessageEmbedField {
+	return field(render(prefix+".name", o, data), render(prefix+".value", o, data))
+}
+
+// inlineParts renders a row of inline fields, one per key under layout
+func inlineParts(layout string, o Overrides, data any, keys ...string) []*discordgo.MessageEmbedField {
+	fields := make([]*discordgo.MessageEmbedField, len(keys))
+	for idx, key := range keys {
+		fields[idx] = part(layout+"."+key, o, data)
+		fields[idx].Inline = true
+	}
+	return fields
+}
+
+func footer(text string) *discordgo.MessageEmbedFooter {
+	if text == "" {
+		return nil
+	}
+	return &discordgo.MessageEmbedFooter{Text: clip(text, maxFooter)}
+}
+
+// textEmbed renders whichever of a layout's title, description and footer
+// parts it defines
+func textEmbed(layout string, o Overrides, data any, color int) *discordgo.MessageEmbed {
+	e := &discordgo.MessageEmbed{Color: color}
+	if defaults.Lookup(layout+".title") != nil {
+		e.Title = clip(render(layout+".title", o, data), maxTitle)
+	}
+	if defaults.Lookup(layout+".description") != nil {
+		e.Description = clip(render(layout+".description", o, data), maxDescription)
+	}
+	if defaults.Lookup(layout+".footer") != nil {
+		e.Footer = footer(render(layout+".footer", o, data))
+	}
+	return e
+}
+
+// CatchView is a landed catch
+type CatchView struct {
+	Angler    string // display name, with title
+	Species   string
+	Article   string // "a" or "an", to go before Species
+	Size      float64
+	SizeClass string
+	Tier      string
+	Color     int
+	ThumbURL  string // species art, when there's no card
+	CardName  string // attached catch card, "" for none
+
+	CatchId  int64 // 0 if it couldn't be stored
+	Streak   int   // days in a row fished
+	Bait     string
+	BaitLeft int
+
+	Weather      string
+	WeatherEmoji string
+	Charges      int // casts left, when the guild allows more than one
+	Capacity     int
+
+	Milestone    bool // Streak is a milestone reached with this catch
+	Achievements []AchievementView
+	Quests       []QuestView
+}
+
+// AchievementView is an achievement unlocked by a catch
+type AchievementView struct {
+	Name        string
+	Description string
+	Title       string
+}
+
+// QuestView is a quest completed by a catch or sale
+type QuestView struct {
+	Description string
+	Reward      int64
+}
+
+// CatchEmbed lays out a landed catch
+func CatchEmbed(v CatchView, o Overrides) *discordgo.MessageEmbed {
+	e := textEmbed("catch", o, v, v.Color)
+	switch {
+	case v.CardName != "":
+		e.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + v.CardName}
+	case v.ThumbURL != "":
+		e.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: v.ThumbURL}
+	}
+
+	if v.Milestone {
+		e.Fields = append(e.Fields, part("catch.milestone", o, v))
+	}
+	for _, a := range v.Achievements {
+		e.Fields = append(e.Fields, part("catch.achievement", o, a))
+	}
+	e.Fields = append(e.Fields, QuestFields(v.Quests, o)...)
+	return e
+}
+
+// QuestFields announces completed quests, on a catch or a sale
+func QuestFields(quests []QuestView, o Overrides) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+	for _, q := range quests {
+		fields = append(fields, part("quest", o, q))
+	}
+	return fields
+}
+
+// ReleasedView is a catch let go from its catch message
+type ReleasedView struct {
+	Points int64 // earned by the release
+	Total  int64 // left to spend
+}
+
+// ReleasedField is added to the catch message when the fish is released
+func ReleasedField(v ReleasedView, o Overrides) *discordgo.MessageEmbedField {
+	return part("catch.released", o, v)
+}
+
+// LeaderboardView is a ranking of single catches, in a guild or globally
+type LeaderboardView struct {
+	Global      bool
+	Species     string // "" for every species
+	Rows        []LeaderboardRow
+	AnyReleased bool
+}
+
+// LeaderboardRow is one ranked catch. Angler is ready to show: a mention in a
+// guild, an escaped display name on the global board.
+type LeaderboardRow struct {
+	Pos       int
+	Size      float64
+	SizeClass string
+	Angler    string
+	Species   string
+	Released  bool
+}
+
+// LeaderboardEmbed lays out a leaderboard
+func LeaderboardEmbed(v LeaderboardView, o Overrides) *discordgo.MessageEmbed {
+	var desc strings.Builder
+	for _, r := range v.Rows {
+		desc.WriteString(render("leaderboard.row", o, r))
+		desc.WriteString("\n")
+	}
+	return &discordgo.MessageEmbed{
+		Title:       clip(render("leaderboard.title", o, v), maxTitle),
+		Description: clip(desc.String(), maxDescription),
+		Color:       0xf1c40f,
+		Footer:      footer(render("leaderboard.footer", o, v)),
+	}
+}
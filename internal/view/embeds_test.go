@@ -0,0 +1,195 @@
+package view
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestEmbedSnapshots(t *testing.T) {
+	catch := samples["catch"].(CatchView)
+	catch.ThumbURL = "https://example.com/trout.png"
+	fight := samples["fight"].(FightView)
+	fight.Moment, fight.Tension = "start", 0.1
+	lost := samples["fight.lost"].(FightLostView)
+	lost.Outcome = "spooled"
+	daily := samples["daily"].(DailyView)
+	daily.Streak, daily.BaitQty = 0, 0
+	sell := samples["sell"].(SellView)
+	sell.CatchId, sell.Quests = 1234, nil
+	inventory := InventoryView{Species: "Perch", Page: 1, Pages: 1}
+	auction := samples["auction"].(AuctionView)
+	auction.ThumbURL = "https://example.com/marlin.png"
+	unsold := auction
+	unsold.Sold = false
+	trade := samples["trade"].(TradeView)
+	trade.Status, trade.CancelledBy = "cancelled", "Partner"
+	profile := samples["profile"].(ProfileView)
+	profile.AvatarURL = "https://example.com/avatar.png"
+	results := samples["tournament"].(TournamentView)
+	upcoming := results
+	upcoming.Upcoming, upcoming.StartsAt, upcoming.Prize, upcoming.Rows = true, 1699900000, 0, nil
+	global := LeaderboardView{Global: true, Rows: samples["leaderboard"].(LeaderboardView).Rows}
+	released := CatchEmbed(samples["catch"].(CatchView), nil)
+	released.Fields = append(released.Fields, ReleasedField(samples["catch.released"].(ReleasedView), nil))
+	overridden := Overrides{"catch.title": "{{.Species}} for {{.Angler}}", "catch.footer": "{{.Broken"}
+
+	tests := []struct {
+		golden string
+		embed  *discordgo.MessageEmbed
+	}{
+		{"catch", CatchEmbed(samples["catch"].(CatchView), nil)},
+		{"catch_thumb", CatchEmbed(catch, nil)},
+		{"catch_released", released},
+		{"catch_override", CatchEmbed(samples["catch"].(CatchView), overridden)},
+		{"leaderboard", LeaderboardEmbed(samples["leaderboard"].(LeaderboardView), nil)},
+		{"leaderboard_global", LeaderboardEmbed(global, nil)},
+		{"reel_cast", ReelCastEmbed(samples["reel"].(ReelView), nil)},
+		{"reel_bite", ReelBiteEmbed(samples["reel"].(ReelView), nil)},
+		{"reel_escaped", ReelEscapedEmbed(samples["reel"].(ReelView), nil)},
+		{"fight", FightEmbed(samples["fight"].(FightView), nil)},
+		{"fight_start", FightEmbed(fight, nil)},
+		{"fight_lost", FightLostEmbed(samples["fight.lost"].(FightLostView), nil)},
+		{"fight_spooled", FightLostEmbed(lost, nil)},
+		{"cooldown", CooldownEmbed(samples["cooldown"].(CooldownView), nil)},
+		{"forecast", ForecastEmbed(samples["forecast"].(ForecastView), nil)},
+		{"daily", DailyEmbed(samples["daily"].(DailyView), nil)},
+		{"daily_no_streak", DailyEmbed(daily, nil)},
+		{"sell", SellEmbed(samples["sell"].(SellView), nil)},
+		{"sell_catch", SellEmbed(sell, nil)},
+		{"shop", ShopEmbed(samples["shop"].(ShopView), nil)},
+		{"market", MarketEmbed(samples["market"].(MarketView), nil)},
+		{"inventory", InventoryEmbed(samples["inventory"].(InventoryView), nil)},
+		{"inventory_empty", InventoryEmbed(inventory, nil)},
+		{"auction_list", AuctionListEmbed(samples["auction.list"].(AuctionsView), nil)},
+		{"auction_list_empty", AuctionListEmbed(AuctionsView{}, nil)},
+		{"auction_new", AuctionNewEmbed(auction, nil)},
+		{"auction_sold", AuctionClosedEmbed(auction, nil)},
+		{"auction_unsold", AuctionClosedEmbed(unsold, nil)},
+		{"trade", TradeEmbed(samples["trade"].(TradeView), nil)},
+		{"trade_cancelled", TradeEmbed(trade, nil)},
+		{"titles", TitlesEmbed(samples["titles"].(TitlesView), nil)},
+		{"conservation", ConservationEmbed(samples["conservation"].(ConservationView), nil)},
+		{"quests", QuestBoardEmbed(samples["quests"].(QuestBoardView), nil)},
+		{"profile", ProfileEmbed(profile, nil)},
+		{"profile_empty", ProfileEmbed(ProfileView{Name: "Angler"}, nil)},
+		{"tournament", TournamentEmbed(samples["tournament"].(TournamentView), nil)},
+		{"tournament_upcoming", TournamentEmbed(upcoming, nil)},
+		{"tournament_results", TournamentResultsEmbed(results, nil)},
+		{"team", TeamBoardEmbed(samples["team"].(TeamBoardView), nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			var got bytes.Buffer
+			enc := json.NewEncoder(&got)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(tt.embed); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", "embeds", tt.golden+".json")
+			if *update {
+				if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("embed differs from %s; if the change is intended, run go test -update\ngot:\n%s", path, got.String())
+			}
+		})
+	}
+}
+
+// Every part must have sample data, or guilds couldn't override it
+func TestTemplatesRenderSamples(t *testing.T) {
+	for _, name := range TemplateNames() {
+		sample, ok := sampleFor(name)
+		if !ok {
+			t.Errorf("%s has no sample data", name)
+			continue
+		}
+		var b strings.Builder
+		if err := defaults.ExecuteTemplate(&b, name, sample); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name, text string
+		want       string
+		wantErr    bool
+	}{
+		{"catch.title", "{{.Angler}} landed {{.Species}}", "Angler landed Rainbow Trout", false},
+		{"auction.new.title", "#{{.Id}}", "#12", false},
+		{"trade.offer.name", "{{.Name}}", "Angler", false},
+		{"catch.title", "{{.Nope}}", "", true},
+		{"catch.title", "{{.Angler", "", true},
+		{"catch", "{{.Angler}}", "", true},
+		{"nope.title", "hi", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ValidateTemplate(tt.name, tt.text)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ValidateTemplate(%q, %q) = %q, %v", tt.name, tt.text, got, err)
+		}
+	}
+}
+
+// Overrides come from server admins, so ones that would run for ever or
+// render gigabytes must fail fast, and render must fall back to the default
+func TestOverrideLimits(t *testing.T) {
+	deep := strings.Repeat("{{range $.Achievements}}", 30) + "x" + strings.Repeat("{{end}}", 30)
+	// Some only blow up on bigger data than the sample, so they pass
+	// ValidateTemplate and are caught when rendered
+	tests := []struct {
+		name, text string
+		dataSized  bool
+	}{
+		{"range over an integer", "{{range 100000000}}xxxxxxxxxx{{end}}", false},
+		{"range over a field integer", "{{range .Streak}}x{{end}}", false},
+		{"output too long", `{{printf "%900000d" 1}}`, false},
+		{"output too long in a loop", `{{range .Achievements}}{{range $.Achievements}}{{printf "%9000d" 1}}{{end}}{{end}}`, true},
+		{"nested loops", deep, true},
+		{"define", `{{define "x"}}{{template "x" .}}{{end}}{{template "x" .}}`, false},
+		{"call a default", `{{template "catch.title" .}}`, false},
+	}
+	catch := samples["catch"].(CatchView)
+	for i := 0; i < 3; i++ {
+		catch.Achievements = append(catch.Achievements, catch.Achievements...)
+	}
+	want := render("catch.title", nil, catch)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			if _, err := ValidateTemplate("catch.title", tt.text); err == nil && !tt.dataSized {
+				t.Errorf("ValidateTemplate accepted %q", tt.text)
+			}
+			if got := render("catch.title", Overrides{"catch.title": tt.text}, catch); got != want {
+				t.Errorf("render = %q, want the default %q", got, want)
+			}
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("took %v", d)
+			}
+		})
+	}
+
+	// Loops over lists still work
+	got, err := ValidateTemplate("leaderboard.title", "{{range $idx, $r := .Rows}}{{$idx}}:{{$r.Species}}{{end}}")
+	if err != nil || got != "0:Blue Marlin" {
+		t.Errorf("ValidateTemplate = %q, %v", got, err)
+	}
+}
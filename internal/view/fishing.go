@@ -0,0 +1,135 @@
+package view
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ReelView is a cast line, from waiting for a bite to the fish escaping
+type ReelView struct {
+	Angler  string
+	Seconds float64 // how long the angler has to press Reel
+}
+
+// ReelCastEmbed shows the line waiting for a bite
+func ReelCastEmbed(v ReelView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("reel.cast", o, v, 0x95a5a6)
+}
+
+// ReelBiteEmbed asks the angler to reel in
+func ReelBiteEmbed(v ReelView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("reel.bite", o, v, 0xe74c3c)
+}
+
+// ReelEscapedEmbed says the angler was too slow
+func ReelEscapedEmbed(v ReelView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("reel.escaped", o, v, 0x95a5a6)
+}
+
+// FightView is a fight in progress. The species stays hidden until the fish
+// is landed.
+type FightView struct {
+	Angler    string
+	Moment    string  // "start", "run", "tiring", "slack" or "reel": what just happened
+	Tension   float64 // 0-1, the line snaps at 1
+	Distance  float64 // metres of line out
+	MovesLeft int
+}
+
+// TensionBar draws the tension meter, going from green to red as the line
+// nears its breaking point
+func (v FightView) TensionBar() string {
+	const width = 10
+	filled := int(v.Tension*width + 0.5)
+	cell := "🟩"
+	switch {
+	case v.Tension >= 0.8:
+		cell = "🟥"
+	case v.Tension >= 0.55:
+		cell = "🟨"
+	case v.Tension <= 0.15:
+		cell = "🟦"
+	}
+	return strings.Repeat(cell, filled) + strings.Repeat("⬛", width-filled)
+}
+
+// TensionPercent is the tension rounded to a whole percentage
+func (v FightView) TensionPercent() int {
+	return int(v.Tension*100 + 0.5)
+}
+
+// FightEmbed lays out a fight in progress
+func FightEmbed(v FightView, o Overrides) *discordgo.MessageEmbed {
+	color := 0x2ecc71
+	if v.Tension >= 0.8 {
+		color = 0xe74c3c
+	} else if v.Tension >= 0.55 {
+		color = 0xf1c40f
+	}
+
+	e := textEmbed("fight", o, v, color)
+	e.Fields = append([]*discordgo.MessageEmbedField{part("fight.tension", o, v)}, inlineParts("fight", o, v, "line", "moves")...)
+	return e
+}
+
+// FightLostView is a fish that got away during a fight
+type FightLostView struct {
+	Angler    string
+	Outcome   string // "snapped", "thrown" or "spooled"
+	Species   string
+	Size      float64
+	SizeClass string
+}
+
+// FightLostEmbed says how the fish got away, and what it was
+func FightLostEmbed(v FightLostView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("fight.lost", o, v, 0x95a5a6)
+}
+
+// CooldownView is the /cooldown overview
+type CooldownView struct {
+	Lines []CooldownLine
+}
+
+// CooldownLine is one rate-limited feature
+type CooldownLine struct {
+	Kind     string // "fish", "leaderboard" or "daily"
+	Until    int64  // unix time it's ready again, 0 when ready
+	Charges  int    // casts left, for "fish"
+	Capacity int    // cast charges the guild allows, for "fish"
+	Next     int64  // unix time the next cast charge comes back, 0 for none
+}
+
+// CooldownEmbed lays out the /cooldown overview
+func CooldownEmbed(v CooldownView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("cooldown", o, v, 0x3498db)
+}
+
+// ForecastView is the weather now and for the periods after it
+type ForecastView struct {
+	Now      ForecastPeriod
+	Upcoming []ForecastPeriod
+}
+
+// ForecastPeriod is one weather period
+type ForecastPeriod struct {
+	Weather string
+	Emoji   string
+	Start   int64 // unix times
+	End     int64
+	Boosts  []WeatherBoost
+}
+
+// WeatherBoost is a fish tag that bites more in some weather
+type WeatherBoost struct {
+	Tag     string
+	Percent float64
+}
+
+// ForecastEmbed lays out the weather forecast
+func ForecastEmbed(v ForecastView, o Overrides) *discordgo.MessageEmbed {
+	e := textEmbed("forecast", o, v, 0x3498db)
+	e.Fields = []*discordgo.MessageEmbedField{part("forecast.upcoming", o, v)}
+	return e
+}
@@ -0,0 +1,120 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Guild overrides are written by server admins but run inside the shared bot
+// process, so they get limits the default templates don't need: a cap on
+// output, no defining or calling other templates, and range only over lists,
+// with a budget on how many loops one execution may start. Together these
+// bound both the memory and the time an override can take.
+const (
+	maxOverrideOutput = 4 * maxDescription // bytes; more than any part can show
+	maxOverrideRanges = 1000               // range loops started per execution
+)
+
+// rangeGuard is called on every range pipeline in an override. It's only
+// added to the function map after parsing, so overrides can't call it.
+const rangeGuard = "_rangeGuard"
+
+var (
+	errOverrideTooLong  = errors.New("template output is too long")
+	errOverrideTooLoopy = errors.New("template loops too many times")
+)
+
+// limitedWriter fails once more than n bytes have been written, which stops
+// the template executing
+type limitedWriter struct {
+	b strings.Builder
+	n int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.b.Len()+len(p) > w.n {
+		return 0, errOverrideTooLong
+	}
+	return w.b.Write(p)
+}
+
+// parseOverride parses a guild's template text and guards its range loops
+func parseOverride(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Templates()) > 1 {
+		return nil, errors.New("templates can't define other templates")
+	}
+	if err := guardRanges(t.Tree, t.Root); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// guardRanges pipes every range through rangeGuard ({{range .Rows}} runs as
+// {{range .Rows | _rangeGuard}}) and rejects calls to other templates
+func guardRanges(tree *parse.Tree, node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := guardRanges(tree, child); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return guardBranch(tree, &n.BranchNode)
+	case *parse.WithNode:
+		return guardBranch(tree, &n.BranchNode)
+	case *parse.RangeNode:
+		guard := parse.NewIdentifier(rangeGuard).SetTree(tree).SetPos(n.Pos)
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{guard}})
+		return guardBranch(tree, &n.BranchNode)
+	case *parse.TemplateNode:
+		return fmt.Errorf("templates can't call other templates (%s)", n.Name)
+	}
+	return nil
+}
+
+func guardBranch(tree *parse.Tree, n *parse.BranchNode) error {
+	if err := guardRanges(tree, n.List); err != nil {
+		return err
+	}
+	return guardRanges(tree, n.ElseList)
+}
+
+// executeOverride runs a guild's template text against data within the
+// override limits
+func executeOverride(name, text string, data any) (string, error) {
+	t, err := parseOverride(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	ranges := 0
+	t.Funcs(template.FuncMap{rangeGuard: func(v any) (any, error) {
+		if ranges++; ranges > maxOverrideRanges {
+			return nil, errOverrideTooLoopy
+		}
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map, reflect.Invalid:
+			return v, nil
+		}
+		// Integers, iterator functions and channels can loop for ever
+		return nil, fmt.Errorf("can only range over lists, not %T", v)
+	}})
+
+	w := &limitedWriter{n: maxOverrideOutput}
+	if err := t.Execute(w, data); err != nil {
+		return "", err
+	}
+	return w.b.String(), nil
+}
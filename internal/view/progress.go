@@ -0,0 +1,117 @@
+package view
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// TitlesView is a user's progress on every achievement
+type TitlesView struct {
+	Earned   int
+	Total    int
+	Equipped string // "" for no title
+	Rows     []TitleRow
+}
+
+// TitleRow is one achievement
+type TitleRow struct {
+	Name        string
+	Description string
+	Title       string // "" if it doesn't unlock one
+	Earned      bool
+	EarnedAt    int64 // unix time
+	Redeemable  bool  // bought with conservation points rather than unlocked
+	Have        int
+	Need        int
+}
+
+// TitlesEmbed lays out achievement progress
+func TitlesEmbed(v TitlesView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("titles", o, v, 0xf1c40f)
+}
+
+// ConservationView is a user's conservation points and the titles they buy
+type ConservationView struct {
+	Points int64
+	Titles []ConservationTitle
+}
+
+// ConservationTitle is a title bought with conservation points
+type ConservationTitle struct {
+	Title    string
+	Cost     int64
+	Redeemed bool
+}
+
+// ConservationEmbed lays out /conservation
+func ConservationEmbed(v ConservationView, o Overrides) *discordgo.MessageEmbed {
+	return textEmbed("conservation", o, v, 0x2ecc71)
+}
+
+// QuestBoardView is a user's active quests
+type QuestBoardView struct {
+	Pools []QuestPoolView
+}
+
+// QuestPoolView is the quests rolled from one pool
+type QuestPoolView struct {
+	Pool   string // "daily" or "weekly"
+	Ends   int64  // unix time new quests are rolled
+	Quests []QuestProgressView
+}
+
+// QuestProgressView is one assigned quest
+type QuestProgressView struct {
+	Description string
+	Progress    int64
+	Target      int64
+	Coins       bool // progress is counted in coins
+	Reward      int64
+	Done        bool
+}
+
+// QuestBoardEmbed lays out /quests, a field per pool
+func QuestBoardEmbed(v QuestBoardView, o Overrides) *discordgo.MessageEmbed {
+	e := textEmbed("quests", o, v, 0x9b59b6)
+	for _, p := range v.Pools {
+		e.Fields = append(e.Fields, part("quests.pool", o, p))
+	}
+	return e
+}
+
+// ProfileView is a user's fishing stats
+type ProfileView struct {
+	Name      string // display name, with title
+	AvatarURL string
+
+	Catches      int // 0 leaves out everything below
+	Released     int
+	Species      int
+	TotalSpecies int
+	Streak       int
+
+	Biggest        string
+	BiggestSize    float64
+	BiggestClass   string
+	Rarest         string
+	RarestTier     string
+	Favourite      string
+	FavouriteCount int
+	Balance        int64
+}
+
+// FishbookPercent is how much of the fishbook the user has filled in
+func (v ProfileView) FishbookPercent() float64 {
+	return 100 * float64(v.Species) / float64(max(v.TotalSpecies, 1))
+}
+
+// ProfileEmbed lays out /profile
+func ProfileEmbed(v ProfileView, o Overrides) *discordgo.MessageEmbed {
+	e := textEmbed("profile", o, v, 0x3498db)
+	if v.AvatarURL != "" {
+		e.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: v.AvatarURL}
+	}
+	if v.Catches > 0 {
+		e.Fields = inlineParts("profile", o, v, "catches", "fishbook", "streak", "biggest", "rarest", "favourite", "wallet")
+	}
+	return e
+}
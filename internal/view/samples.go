@@ -0,0 +1,150 @@
+package view
+
+import "strings"
+
+// samples are the data each layout is checked against before a guild's
+// override is saved, and what the snapshot tests render. They're keyed by
+// layout; sampleFor finds the one for a part.
+var samples = func() map[string]any {
+	catch := CatchView{
+		Angler: "Angler", Species: "Rainbow Trout", Article: "a", Size: 61.5,
+		SizeClass: "big", Tier: "Uncommon", CatchId: 1234, Streak: 7,
+		Bait: "Worms", BaitLeft: 4, Weather: "Rain", WeatherEmoji: "🌧️",
+		Charges: 2, Capacity: 3, Milestone: true, Color: 0x3498db,
+	}
+	achievement := AchievementView{Name: "Shark Week", Description: "Catch 10 sharks", Title: "Shark Hunter"}
+	quest := QuestView{Description: "Catch 5 fish", Reward: 50}
+	catch.Achievements = []AchievementView{achievement}
+	catch.Quests = []QuestView{quest}
+	row := LeaderboardRow{Pos: 1, Size: 412.3, SizeClass: "enormous", Angler: "<@0>", Species: "Blue Marlin", Released: true}
+
+	auction := AuctionView{
+		Id: 12, SellerId: 100, Species: "Blue Marlin", Size: 388.2, Tier: "Legendary", Color: 0xf1c40f,
+		MinBid: 400, TopBid: 520, TopBidderId: 200, EndsAt: 1700000000, Sold: true,
+	}
+	unbid := auction
+	unbid.Id, unbid.TopBid, unbid.TopBidderId, unbid.Sold = 13, 0, 0, false
+
+	offer := TradeOffer{
+		Name: "Angler", Confirmed: true, Coins: 50,
+		Items: []TradeItem{
+			{CatchId: 1234, Species: "Rainbow Trout", Size: 61.5, Value: 35},
+			{CatchId: 1240, Gone: true},
+		},
+	}
+	pool := QuestPoolView{
+		Pool: "daily", Ends: 1700000000,
+		Quests: []QuestProgressView{
+			{Description: "Catch 5 fish", Progress: 2, Target: 5, Reward: 50},
+			{Description: "Sell fish worth 100 🪙", Progress: 100, Target: 100, Coins: true, Reward: 80, Done: true},
+		},
+	}
+	slot := ShopSlot{Slot: "rod", Items: []ShopGear{
+		{Name: "Bamboo Rod", Key: "bamboo", Description: "A trusty starter rod.", Equipped: true, Owned: true},
+		{Name: "Carbon Rod", Key: "carbon", Description: "Light and strong.", Price: 500},
+	}}
+
+	return map[string]any{
+		"catch":             catch,
+		"catch.achievement": achievement,
+		"catch.released":    ReleasedView{Points: 4, Total: 39},
+		"quest":             quest,
+		"leaderboard":       LeaderboardView{Species: "Blue Marlin", Rows: []LeaderboardRow{row}, AnyReleased: true},
+		"leaderboard.row":   row,
+
+		"reel": ReelView{Angler: "Angler", Seconds: 2.5},
+		"fight": FightView{
+			Angler: "Angler", Moment: "run", Tension: 0.62, Distance: 34, MovesLeft: 9,
+		},
+		"fight.lost": FightLostView{Angler: "Angler", Outcome: "snapped", Species: "Blue Marlin", Size: 402.7, SizeClass: "huge"},
+		"cooldown": CooldownView{Lines: []CooldownLine{
+			{Kind: "fish", Charges: 1, Capacity: 3, Next: 1700000000},
+			{Kind: "leaderboard", Until: 1700000060},
+			{Kind: "daily", Until: 1700050000},
+		}},
+		"forecast": ForecastView{
+			Now: ForecastPeriod{
+				Weather: "Rain", Emoji: "🌧️", Start: 1699990000, End: 1700000000,
+				Boosts: []WeatherBoost{{Tag: "freshwater", Percent: 50}, {Tag: "river", Percent: 25}},
+			},
+			Upcoming: []ForecastPeriod{
+				{Weather: "Fog", Emoji: "🌫️", Start: 1700000000, End: 1700010000, Boosts: []WeatherBoost{{Tag: "deep sea", Percent: 75}}},
+				{Weather: "Clear", Emoji: "☀️", Start: 1700010000, End: 1700020000},
+			},
+		},
+
+		"daily": DailyView{
+			Coins: 120, Bait: "Worms", BaitQty: 10, Streak: 7, StreakBonus: 10, Balance: 1520, Timezone: "Europe/London",
+		},
+		"sell": SellView{Count: 3, Total: 140, Balance: 1660, Quests: []QuestView{quest}},
+		"shop": ShopView{
+			Balance: 1520,
+			Slots:   []ShopSlot{slot, {Slot: "reel"}},
+			Bait:    []ShopBait{{Name: "Worms", Key: "worms", Description: "Everything eats worms.", Price: 20, Pack: 10, Have: 4}},
+		},
+		"shop.slot": slot,
+		"market": MarketView{Days: 7, AtBase: 41, Rows: []MarketRow{
+			{Species: "Blue Marlin", Price: 310, Prev: 340, Base: 400, History: []int64{400, 400, 380, 360, 350, 340, 310}},
+			{Species: "Rainbow Trout", Price: 30, Prev: 28, Base: 30, History: []int64{30, 26, 25, 25, 27, 28, 30}},
+		}},
+		"market.row": MarketRow{Species: "Blue Marlin", Price: 310, Prev: 340, Base: 400, History: []int64{400, 380, 310}},
+		"inventory": InventoryView{
+			Page: 1, Pages: 2, Count: 12, Total: 640,
+			Rows: []InventoryRow{
+				{Id: 1234, Species: "Rainbow Trout", Size: 61.5, SizeClass: "big", Tier: "Uncommon", Value: 35},
+				{Id: 1201, Species: "Perch", Size: 18.2, SizeClass: "modest", Tier: "Common", Value: 6},
+			},
+		},
+		"auction":      auction,
+		"auction.list": AuctionsView{Rows: []AuctionView{auction, unbid}, More: 3},
+		"trade": TradeView{
+			Id: 7, Initiator: "100", Partner: "200",
+			Offers: []TradeOffer{offer, {Name: "Partner"}},
+		},
+		"trade.offer": offer,
+
+		"titles": TitlesView{Earned: 1, Total: 3, Equipped: "Shark Hunter", Rows: []TitleRow{
+			{Name: "Shark Week", Description: "Catch 10 sharks", Title: "Shark Hunter", Earned: true, EarnedAt: 1700000000},
+			{Name: "Regular", Description: "Catch 100 fish", Have: 42, Need: 100},
+			{Name: "Friend of the Sea", Description: "Buy with conservation points", Title: "Conservationist", Redeemable: true},
+		}},
+		"conservation": ConservationView{Points: 35, Titles: []ConservationTitle{
+			{Title: "Conservationist", Cost: 20, Redeemed: true},
+			{Title: "Guardian of the Reef", Cost: 100},
+		}},
+		"quests":      QuestBoardView{Pools: []QuestPoolView{pool, {Pool: "weekly", Ends: 1700500000}}},
+		"quests.pool": pool,
+		"profile": ProfileView{
+			Name: "Angler, *Shark Hunter*", Catches: 212, Released: 9, Species: 31, TotalSpecies: 64, Streak: 7,
+			Biggest: "Blue Marlin", BiggestSize: 412.3, BiggestClass: "enormous",
+			Rarest: "Coelacanth", RarestTier: "Mythic", Favourite: "Perch", FavouriteCount: 48, Balance: 1520,
+		},
+
+		"tournament": TournamentView{
+			Id: 3, Name: "Spring Derby", Metric: "largest", Target: "any fish", EndsAt: 1700000000, Prize: 1000, Entrants: 5,
+			Rows: []StandingRow{
+				{Place: 1, UserId: 100, Score: "412.3 cm", Size: 412.3, Species: "Blue Marlin", Prize: 600},
+				{Place: 2, UserId: 200, Score: "61.5 cm", Size: 61.5, Species: "Rainbow Trout", Prize: 400},
+				{Place: 4, UserId: 300, Score: "18.2 cm", Size: 18.2, Species: "Perch"},
+			},
+		},
+		"team": TeamBoardView{Period: "week", Rows: []TeamRow{
+			{Pos: 1, Name: "The Reel Deal", Catches: 88, Species: 21, Members: 4, BestSize: 412.3, BestSpecies: "Blue Marlin", BestClass: "enormous", BestUserId: 100},
+		}},
+	}
+}()
+
+// sampleFor finds the sample data for a part: the sample of the longest
+// layout name it starts with
+func sampleFor(name string) (any, bool) {
+	for key := name; ; {
+		if sample, ok := samples[key]; ok {
+			return sample, true
+		}
+		dot := strings.LastIndex(key, ".")
+		if dot < 0 {
+			return nil, false
+		}
+		key = key[:dot]
+	}
+}